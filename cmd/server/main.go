@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"sweng-task/internal/config"
+	"sweng-task/internal/geoip"
 	"sweng-task/internal/handler"
+	"sweng-task/internal/model"
 	"sweng-task/internal/repo"
 	"sweng-task/internal/service"
+	"sweng-task/internal/sink"
 	"sweng-task/internal/validation"
+	"sweng-task/internal/webhook"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -46,15 +53,131 @@ func main() {
 	// Initialize repositories
 	trackingRepo := repo.NewTrackingEventRepository(log)
 	lineItemRepo := repo.NewLineItemRepository(log)
+	advertiserRepo := repo.NewAdvertiserRepository(log)
+	campaignRepo := repo.NewCampaignRepository(log)
+	creativeRepo := repo.NewCreativeRepository(log)
+	segmentRepo := repo.NewSegmentRepository(log)
+	audienceRepo := repo.NewAudienceRepository(log)
+	bidRepo := repo.NewBidRepository(log)
+	dealRepo := repo.NewDealRepository(log)
+	inventoryRepo := repo.NewInventoryRepository(log)
+
+	// Initialize tracking event sinks
+	eventSinks, err := sink.NewFromConfig(cfg.Sink, log)
+	if err != nil {
+		log.Fatalf("Failed to initialize event sinks: %v", err)
+	}
+	// Flushes the queued events after the server is shut down
+	defer eventSinks.Close()
+
+	// Initialize the ad request log, restoring the inventory counts forecast from its files
+	var requestSink service.AdRequestSink
+	if cfg.RequestLog.Path != "" {
+		restored, err := restoreInventory(cfg.RequestLog.Path, inventoryRepo, time.Now().Add(-cfg.Forecast.Lookback))
+		if err != nil {
+			log.Fatalf("Failed to read ad request log: %v", err)
+		}
+		log.Infow("Inventory history restored", "path", cfg.RequestLog.Path, "requests", restored)
+		requestLog, err := sink.OpenRequestLog(cfg.RequestLog.Path, cfg.RequestLog.RotateMaxBytes, cfg.RequestLog.RotateMaxAge, cfg.RequestLog.QueueSize, log)
+		if err != nil {
+			log.Fatalf("Failed to open ad request log: %v", err)
+		}
+		// Flushes the queued requests after the server is shut down
+		defer requestLog.Close()
+		requestSink = requestLog
+	}
 
 	// Initialize services
-	lineItemService := service.NewLineItemService(lineItemRepo, log)
-	adService := service.NewAdService(lineItemRepo, lineItemService, log)
-	trackingService := service.NewTrackingService(trackingRepo, log)
+	advertiserService := service.NewAdvertiserService(advertiserRepo, log)
+	campaignService := service.NewCampaignService(campaignRepo, advertiserService, log)
+	placementSizes := make(map[string][]string, len(cfg.Review.PlacementSizes))
+	for placement, sizes := range cfg.Review.PlacementSizes {
+		placementSizes[placement] = strings.Split(sizes, "|")
+	}
+	creativeService := service.NewCreativeService(creativeRepo, advertiserService, service.CreativePolicy{
+		BlockedDomains:  cfg.Review.BlockedDomains,
+		PlacementSizes:  placementSizes,
+		VideoPlacements: cfg.VAST.Placements,
+	}, log)
+	dealTiers := make([]model.DealPriceType, 0, len(cfg.Deal.PriorityTiers))
+	for _, tier := range cfg.Deal.PriorityTiers {
+		dealTiers = append(dealTiers, model.DealPriceType(tier))
+	}
+	dealService := service.NewDealService(dealRepo, dealTiers, log)
+	segmentService := service.NewSegmentService(segmentRepo, cfg.Segment.DefaultTTL, log)
+	audienceService := service.NewAudienceService(audienceRepo, trackingRepo, lineItemRepo, advertiserService, segmentService, log)
+	lineItemService := service.NewLineItemService(lineItemRepo, advertiserService, campaignService, creativeService, audienceService, dealService, log)
+	var geoLocator service.GeoLocator
+	if cfg.GeoIP.DatabasePath != "" {
+		geoDB, err := geoip.Open(cfg.GeoIP.DatabasePath)
+		if err != nil {
+			log.Fatalf("Failed to load GeoIP database: %v", err)
+		}
+		log.Infow("GeoIP database loaded", "path", cfg.GeoIP.DatabasePath, "networks", geoDB.Len())
+		geoLocator = geoDB
+	}
+	var underdeliveryNotifier service.UnderdeliveryNotifier
+	if cfg.Delivery.WebhookURL != "" {
+		underdeliveryNotifier = webhook.New(cfg.Delivery.WebhookURL, cfg.Delivery.WebhookTimeout)
+	}
+	deliveryService := service.NewDeliveryService(lineItemRepo, trackingRepo, service.PacingRules{
+		MaxBoost:               cfg.Delivery.MaxBoost,
+		UnderdeliveryTolerance: cfg.Delivery.UnderdeliveryTolerance,
+		ForecastWarmup:         cfg.Delivery.ForecastWarmup,
+	}, underdeliveryNotifier, log)
+	adRequestLog := service.NewAdRequestLog(inventoryRepo, requestSink, cfg.RequestLog.SampleRate, log)
+	adService := service.NewAdService(service.AdServiceDeps{
+		LineItemRepo:    lineItemRepo,
+		LineItemService: lineItemService,
+		AdvertiserRepo:  advertiserRepo,
+		CampaignRepo:    campaignRepo,
+		CreativeService: creativeService,
+		Geo:             geoLocator,
+		Segments:        segmentService,
+		Deals:           dealService,
+		Delivery:        deliveryService,
+		Requests:        adRequestLog,
+	}, service.AdSelectionRules{
+		Separation: service.NewSeparationRules(cfg.Separation.MaxAdsPerAdvertiser, cfg.Separation.ExclusiveCategories,
+			cfg.Separation.PlacementMaxAdsPerAdvertiser, cfg.Separation.PlacementExclusiveCategories),
+		Weights: service.ScoringWeights{
+			Category: cfg.Scoring.CategoryWeight,
+			Keyword:  cfg.Scoring.KeywordWeight,
+		},
+		Floors: service.FloorRules{
+			Default:    cfg.Floor.Default,
+			Placements: cfg.Floor.Placements,
+		},
+	}, log)
+	attributionService := service.NewAttributionService(trackingRepo, lineItemRepo, service.AttributionWindows{
+		Click: cfg.Attribution.ClickLookback,
+		View:  cfg.Attribution.ViewLookback,
+	}, log)
+	var ivtFilter *service.InvalidTrafficFilter
+	if cfg.IVT.Enabled {
+		ivtFilter = service.NewInvalidTrafficFilter(trackingRepo, service.InvalidTrafficRules{
+			BotUserAgents:      cfg.IVT.BotUserAgents,
+			BurstWindow:        cfg.IVT.BurstWindow,
+			MaxEventsPerIP:     cfg.IVT.MaxEventsPerIP,
+			MaxEventsPerUser:   cfg.IVT.MaxEventsPerUser,
+			ImpressionLookback: cfg.IVT.ImpressionLookback,
+			MinClickDelay:      cfg.IVT.MinClickDelay,
+		}, log)
+	}
+	trackingService := service.NewTrackingService(trackingRepo, attributionService, ivtFilter, eventSinks, adService, log)
+	trackingService.Subscribe(creativeService)
+	trackingService.Subscribe(audienceService)
+	trackingService.Subscribe(deliveryService)
+	auctionService := service.NewAuctionService(adService, bidRepo, trackingService, log)
+	reportService := service.NewReportService(trackingRepo, lineItemRepo, log)
+	forecastService := service.NewForecastService(inventoryRepo, lineItemRepo, cfg.Forecast.Lookback, log)
 
 	// Setup Fiber app
 	app := fiber.New(fiber.Config{
-		AppName:      "Ad Bidding Service",
+		AppName: "Ad Bidding Service",
+		// Values taken from requests outlive their handlers, in stored tracking
+		// events and queued request logs, so they must not reuse request buffers
+		Immutable:    true,
 		ReadTimeout:  cfg.Server.Timeout,
 		WriteTimeout: cfg.Server.Timeout,
 		IdleTimeout:  cfg.Server.Timeout,
@@ -70,19 +193,97 @@ func main() {
 
 	api := app.Group("/api/v1")
 
+	// Advertiser endpoints
+	advertiserHandler := handler.NewAdvertiserHandler(advertiserService, validate, log)
+	api.Post("/advertisers", advertiserHandler.Create)
+	api.Get("/advertisers", advertiserHandler.GetAll)
+	api.Get("/advertisers/:id", advertiserHandler.GetByID)
+	api.Patch("/advertisers/:id", advertiserHandler.Update)
+	api.Put("/advertisers/:id/blocklist", advertiserHandler.UpdateBlocklist)
+
+	// Campaign endpoints
+	campaignHandler := handler.NewCampaignHandler(campaignService, validate, log)
+	api.Post("/campaigns", campaignHandler.Create)
+	api.Get("/campaigns", campaignHandler.GetAll)
+	api.Get("/campaigns/:id", campaignHandler.GetByID)
+	api.Patch("/campaigns/:id", campaignHandler.Update)
+
+	// Creative endpoints
+	creativeHandler := handler.NewCreativeHandler(creativeService, validate, log)
+	api.Post("/creatives", creativeHandler.Create)
+	api.Get("/creatives", creativeHandler.GetAll)
+	api.Get("/creatives/:id", creativeHandler.GetByID)
+	api.Post("/admin/creatives/:id/approve", creativeHandler.Approve)
+	api.Post("/admin/creatives/:id/reject", creativeHandler.Reject)
+
 	// Line Item endpoints
 	lineItemHandler := handler.NewLineItemHandler(lineItemService, validate, log)
 	api.Post("/lineitems", lineItemHandler.Create)
 	api.Get("/lineitems", lineItemHandler.GetAll)
 	api.Get("/lineitems/:id", lineItemHandler.GetByID)
+	api.Put("/lineitems/:id/blocklist", lineItemHandler.UpdateBlocklist)
+	api.Post("/lineitems/:id/creatives", lineItemHandler.AttachCreative)
+	api.Delete("/lineitems/:id/creatives/:creative_id", lineItemHandler.DetachCreative)
+
+	// Audience segment endpoints
+	segmentHandler := handler.NewSegmentHandler(segmentService, validate, log)
+	api.Post("/segments/memberships", segmentHandler.Upload)
+	api.Get("/users/:id/segments", segmentHandler.GetUserSegments)
+	api.Delete("/users/:id/segments/:segment_id", segmentHandler.RemoveMembership)
+
+	// Deal endpoints
+	dealHandler := handler.NewDealHandler(dealService, validate, log)
+	api.Post("/deals", dealHandler.Create)
+	api.Get("/deals", dealHandler.GetAll)
+	api.Get("/deals/:id", dealHandler.GetByID)
+
+	// Audience endpoints
+	audienceHandler := handler.NewAudienceHandler(audienceService, validate, log)
+	api.Post("/audiences", audienceHandler.Create)
+	api.Get("/audiences", audienceHandler.GetAll)
+	api.Get("/audiences/:id", audienceHandler.GetByID)
 
 	// Ad endpoints - TO BE IMPLEMENTED BY CANDIDATE
-	adHandler := handler.NewAdHandler(adService, log)
+	adHandler := handler.NewAdHandler(adService, cfg.VAST.Placements, cfg.VAST.TrackingBaseURL, log)
 	api.Get("/ads", adHandler.GetWinningAds)
 
 	// Tracking endpoint - TO BE IMPLEMENTED BY CANDIDATE
 	trackingHandler := handler.NewTrackingHandler(trackingService, log)
 	api.Post("/tracking", trackingHandler.TrackEvent)
+	api.Get("/tracking/pixel", trackingHandler.TrackPixel)
+	api.Get("/tracking/events", trackingHandler.GetEvents)
+	api.Get("/lineitems/:id/stats", trackingHandler.GetLineItemStats)
+	api.Get("/tracking/sinks", trackingHandler.GetSinkStats)
+
+	// Delivery endpoints
+	deliveryHandler := handler.NewDeliveryHandler(deliveryService, log)
+	api.Get("/delivery", deliveryHandler.GetGuaranteed)
+	api.Get("/lineitems/:id/delivery", deliveryHandler.GetByLineItemID)
+
+	// Forecast endpoints
+	forecastHandler := handler.NewForecastHandler(forecastService, log)
+	api.Post("/forecast", forecastHandler.Forecast)
+
+	// Report endpoints
+	reportHandler := handler.NewReportHandler(reportService, log)
+	api.Get("/reports", reportHandler.GetReport)
+
+	// Delete expired segment memberships, bids and ad requests and check delivery in the background
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go segmentService.RunExpiry(ctx, cfg.Segment.ExpiryInterval)
+	go auctionService.RunExpiry(ctx, cfg.OpenRTB.ExpiryInterval, cfg.OpenRTB.BidTTL, cfg.OpenRTB.BillingTTL)
+	go deliveryService.RunAlerts(ctx, cfg.Delivery.AlertInterval)
+	go forecastService.RunExpiry(ctx, cfg.Forecast.ExpiryInterval)
+
+	// OpenRTB endpoints
+	openRTBHandler := handler.NewOpenRTBHandler(auctionService, cfg.OpenRTB.NoticeBaseURL, log)
+	openRTB := app.Group("/openrtb2")
+	openRTB.Post("/auction", openRTBHandler.Auction)
+	openRTB.Get("/win/:bid_id", openRTBHandler.Win)
+	openRTB.Get("/bill/:bid_id", openRTBHandler.Bill)
+	openRTB.Get("/loss/:bid_id", openRTBHandler.Loss)
+	openRTB.Post("/prebid", openRTBHandler.Prebid)
 
 	// Start server
 	go func() {
@@ -105,3 +306,17 @@ func main() {
 
 	log.Info("Server gracefully stopped")
 }
+
+// restoreInventory counts the ad requests logged since the given time in the
+// inventory and returns their number
+func restoreInventory(path string, inventoryRepo repo.InventoryRepository, since time.Time) (int, error) {
+	restored := 0
+	err := sink.ReadRequestLogFiles(path, func(request *model.AdRequest) error {
+		if request.Timestamp.Before(since) {
+			return nil
+		}
+		restored++
+		return inventoryRepo.AddInventory(request.InventoryCount())
+	})
+	return restored, err
+}
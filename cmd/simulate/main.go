@@ -0,0 +1,206 @@
+// Command simulate replays a recorded ad request log against a snapshot of
+// advertisers, campaigns, creatives, line items and deals, once with the
+// configuration of the service and once with the alternative configuration
+// given by flags, and reports the differences in fill rate, revenue and
+// per-advertiser spend.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/config"
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+	"sweng-task/internal/sink"
+)
+
+// report is the JSON output of a simulation
+type report struct {
+	Baseline    *service.SimulationResult `json:"baseline"`
+	Alternative *service.SimulationResult `json:"alternative"`
+}
+
+func main() {
+	requestsPath := flag.String("requests", "", "path of the NDJSON ad request log, its rotated files are read too")
+	snapshotPath := flag.String("snapshot", "", "path of the JSON snapshot of advertisers, campaigns, creatives, line_items and deals")
+	categoryWeight := flag.Float64("category-weight", 0, "relevancy bonus of line items matching the request category")
+	keywordWeight := flag.Float64("keyword-weight", 0, "relevancy bonus of line items matching the request keyword")
+	floor := flag.Float64("floor", 0, "minimum bid per impression of placements without their own floor")
+	placementFloors := flag.String("placement-floors", "", "minimum bids per impression of placements, ex: homepage_top:0.5,sidebar:0.2")
+	maxAdsPerAdvertiser := flag.Int("max-ads-per-advertiser", 0, "maximum ads of one advertiser per response on placements without their own maximum, zero means unlimited")
+	dealTiers := flag.String("deal-tiers", "", "deal price types in priority order, ex: fixed,floor")
+	jsonOutput := flag.Bool("json", false, "print the results as JSON")
+	flag.Parse()
+
+	if *requestsPath == "" || *snapshotPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fatalf("Failed to load configuration: %v", err)
+	}
+	snapshot, err := readSnapshot(*snapshotPath)
+	if err != nil {
+		fatalf("Failed to read snapshot: %v", err)
+	}
+	var requests []*model.AdRequest
+	err = sink.ReadRequestLogFiles(*requestsPath, func(request *model.AdRequest) error {
+		requests = append(requests, request)
+		return nil
+	})
+	if err != nil {
+		fatalf("Failed to read ad request log: %v", err)
+	}
+
+	// the baseline is the configuration of the service, flags override it in the alternative
+	baselineConfig := simulationConfig(cfg)
+	alternativeConfig := simulationConfig(cfg)
+	var flagErr error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "category-weight":
+			alternativeConfig.Weights.Category = *categoryWeight
+		case "keyword-weight":
+			alternativeConfig.Weights.Keyword = *keywordWeight
+		case "floor":
+			alternativeConfig.Floors.Default = *floor
+		case "placement-floors":
+			alternativeConfig.Floors.Placements, flagErr = parsePlacementFloors(*placementFloors)
+		case "max-ads-per-advertiser":
+			alternativeConfig.Separation = service.NewSeparationRules(*maxAdsPerAdvertiser, cfg.Separation.ExclusiveCategories,
+				cfg.Separation.PlacementMaxAdsPerAdvertiser, cfg.Separation.PlacementExclusiveCategories)
+		case "deal-tiers":
+			alternativeConfig.DealTiers = parseDealTiers(strings.Split(*dealTiers, ","))
+		}
+	})
+	if flagErr != nil {
+		fatalf("Invalid flag: %v", flagErr)
+	}
+
+	log := zap.NewNop().Sugar()
+	baseline, err := service.Simulate(snapshot, requests, baselineConfig, log)
+	if err != nil {
+		fatalf("Failed to simulate the baseline: %v", err)
+	}
+	alternative, err := service.Simulate(snapshot, requests, alternativeConfig, log)
+	if err != nil {
+		fatalf("Failed to simulate the alternative: %v", err)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report{Baseline: baseline, Alternative: alternative}); err != nil {
+			fatalf("Failed to write results: %v", err)
+		}
+		return
+	}
+	printReport(len(requests), baseline, alternative)
+}
+
+// simulationConfig returns the ad selection configuration of the service
+func simulationConfig(cfg *config.Config) service.SimulationConfig {
+	return service.SimulationConfig{
+		Separation: service.NewSeparationRules(cfg.Separation.MaxAdsPerAdvertiser, cfg.Separation.ExclusiveCategories,
+			cfg.Separation.PlacementMaxAdsPerAdvertiser, cfg.Separation.PlacementExclusiveCategories),
+		Weights: service.ScoringWeights{
+			Category: cfg.Scoring.CategoryWeight,
+			Keyword:  cfg.Scoring.KeywordWeight,
+		},
+		Floors: service.FloorRules{
+			Default:    cfg.Floor.Default,
+			Placements: cfg.Floor.Placements,
+		},
+		DealTiers: parseDealTiers(cfg.Deal.PriorityTiers),
+		Pacing: service.PacingRules{
+			MaxBoost:               cfg.Delivery.MaxBoost,
+			UnderdeliveryTolerance: cfg.Delivery.UnderdeliveryTolerance,
+			ForecastWarmup:         cfg.Delivery.ForecastWarmup,
+		},
+	}
+}
+
+func readSnapshot(path string) (*service.Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot service.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// parsePlacementFloors parses "<placement>:<floor>" pairs separated by commas
+func parsePlacementFloors(value string) (map[string]float64, error) {
+	floors := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		placement, floor, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("placement floor %q is not <placement>:<floor>", pair)
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(floor), 64)
+		if err != nil {
+			return nil, fmt.Errorf("placement floor %q: %w", pair, err)
+		}
+		floors[strings.TrimSpace(placement)] = parsed
+	}
+	return floors, nil
+}
+
+func parseDealTiers(tiers []string) []model.DealPriceType {
+	result := make([]model.DealPriceType, 0, len(tiers))
+	for _, tier := range tiers {
+		if tier = strings.TrimSpace(tier); tier != "" {
+			result = append(result, model.DealPriceType(tier))
+		}
+	}
+	return result
+}
+
+func printReport(logged int, baseline, alternative *service.SimulationResult) {
+	fmt.Printf("Replayed %d logged ad requests, %d failed in the baseline and %d in the alternative\n\n", logged, baseline.Failed, alternative.Failed)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "\tbaseline\talternative\tdifference\t")
+	fmt.Fprintf(w, "requests\t%.0f\t%.0f\t\t\n", baseline.Requests, alternative.Requests)
+	fmt.Fprintf(w, "fill rate\t%.2f%%\t%.2f%%\t%+.2f pp\t\n", baseline.FillRate()*100, alternative.FillRate()*100,
+		(alternative.FillRate()-baseline.FillRate())*100)
+	fmt.Fprintf(w, "impressions\t%.0f\t%.0f\t%+.0f\t\n", baseline.Impressions, alternative.Impressions, alternative.Impressions-baseline.Impressions)
+	fmt.Fprintf(w, "revenue\t%.2f\t%.2f\t%+.2f\t\n", baseline.Revenue, alternative.Revenue, alternative.Revenue-baseline.Revenue)
+
+	var advertisers []string
+	for id := range baseline.AdvertiserSpend {
+		advertisers = append(advertisers, id)
+	}
+	for id := range alternative.AdvertiserSpend {
+		if _, ok := baseline.AdvertiserSpend[id]; !ok {
+			advertisers = append(advertisers, id)
+		}
+	}
+	slices.Sort(advertisers)
+	for _, id := range advertisers {
+		base, alt := baseline.AdvertiserSpend[id], alternative.AdvertiserSpend[id]
+		fmt.Fprintf(w, "spend %s\t%.2f\t%.2f\t%+.2f\t\n", id, base, alt, alt-base)
+	}
+	w.Flush()
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -10,6 +10,35 @@ import (
 type Config struct {
 	App    AppConfig    `split_words:"true"`
 	Server ServerConfig `split_words:"true"`
+	Sink   SinkConfig   `split_words:"true"`
+	// Attribution contains default conversion lookback windows
+	Attribution AttributionConfig `split_words:"true"`
+	// IVT contains invalid traffic filter thresholds
+	IVT IVTConfig
+	// Review contains creative review policy
+	Review ReviewConfig
+	// Separation contains rules for ads returned together
+	Separation SeparationConfig
+	// Scoring contains the relevancy scoring weights
+	Scoring ScoringConfig
+	// Floor contains the minimum bids of placements
+	Floor FloorConfig
+	// GeoIP contains the location database for geo targeting
+	GeoIP GeoIPConfig
+	// Segment contains audience segment membership expiry
+	Segment SegmentConfig
+	// OpenRTB contains the external auction configuration
+	OpenRTB OpenRTBConfig
+	// VAST contains the video placement configuration
+	VAST VASTConfig
+	// Deal contains the private marketplace configuration
+	Deal DealConfig
+	// Delivery contains guaranteed delivery pacing and alert configuration
+	Delivery DeliveryConfig
+	// Forecast contains the ad request history of inventory forecasts
+	Forecast ForecastConfig
+	// RequestLog contains the ad request log configuration
+	RequestLog RequestLogConfig `split_words:"true"`
 }
 
 // AppConfig contains application-specific configuration
@@ -26,6 +55,152 @@ type ServerConfig struct {
 	Timeout time.Duration `default:"30s"`
 }
 
+// SinkConfig contains tracking event sink configuration
+type SinkConfig struct {
+	// Types lists the enabled sinks: stdout, file, rotating, kafka
+	Types          []string      `split_words:"true"`
+	FilePath       string        `default:"data/tracking.ndjson" split_words:"true"`
+	RotatePath     string        `default:"data/tracking-rotating.ndjson" split_words:"true"`
+	RotateMaxBytes int64         `default:"104857600" split_words:"true"`
+	RotateMaxAge   time.Duration `default:"1h" split_words:"true"`
+	KafkaBroker    string        `default:"localhost:9092" split_words:"true"`
+	KafkaTopic     string        `default:"tracking-events" split_words:"true"`
+	KafkaPartition int32         `default:"0" split_words:"true"`
+	KafkaAcks      int16         `default:"1" split_words:"true"`
+	KafkaTimeout   time.Duration `default:"5s" split_words:"true"`
+	// QueueSize is the number of events queued per sink, further events are dropped
+	QueueSize int `default:"10000" split_words:"true"`
+}
+
+// AttributionConfig contains conversion attribution configuration
+type AttributionConfig struct {
+	ClickLookback time.Duration `default:"168h" split_words:"true"`
+	ViewLookback  time.Duration `default:"24h" split_words:"true"`
+}
+
+// IVTConfig contains invalid traffic filter configuration
+type IVTConfig struct {
+	Enabled bool `default:"true"`
+	// BotUserAgents are case-insensitive substrings of known bot user agents
+	BotUserAgents      []string      `default:"bot,crawler,spider,headless,curl,wget,python-requests" split_words:"true"`
+	BurstWindow        time.Duration `default:"1m" split_words:"true"`
+	MaxEventsPerIP     int           `default:"120" split_words:"true"`
+	MaxEventsPerUser   int           `default:"60" split_words:"true"`
+	ImpressionLookback time.Duration `default:"24h" split_words:"true"`
+	MinClickDelay      time.Duration `default:"1s" split_words:"true"`
+}
+
+// ReviewConfig contains creative review policy configuration
+type ReviewConfig struct {
+	// BlockedDomains are landing page domains which are rejected, including their subdomains
+	BlockedDomains []string `split_words:"true"`
+	// PlacementSizes maps placements to their allowed "<width>x<height>" sizes separated by "|",
+	// ex: "homepage_top:728x90|970x250,sidebar:300x250". Unlisted placements allow any size.
+	PlacementSizes map[string]string `split_words:"true"`
+}
+
+// SeparationConfig contains rules for ads returned together in one response
+type SeparationConfig struct {
+	// MaxAdsPerAdvertiser is the maximum number of ads of one advertiser, zero means unlimited
+	MaxAdsPerAdvertiser int `split_words:"true"`
+	// ExclusiveCategories are competitive advertiser categories of which at most one advertiser is returned
+	ExclusiveCategories []string `split_words:"true"`
+	// PlacementMaxAdsPerAdvertiser overrides MaxAdsPerAdvertiser per placement, ex: "sidebar:2"
+	PlacementMaxAdsPerAdvertiser map[string]int `split_words:"true"`
+	// PlacementExclusiveCategories overrides ExclusiveCategories per placement with categories
+	// separated by "|", ex: "homepage_top:automotive|telecom"
+	PlacementExclusiveCategories map[string]string `split_words:"true"`
+}
+
+// ScoringConfig contains the relevancy score bonuses of line items matching the request
+type ScoringConfig struct {
+	CategoryWeight float64 `default:"0.3" split_words:"true"`
+	KeywordWeight  float64 `default:"0.2" split_words:"true"`
+}
+
+// FloorConfig contains the minimum bids per impression of placements
+type FloorConfig struct {
+	// Default is the floor of placements without their own, zero means no floor
+	Default float64
+	// Placements overrides the default floor per placement, ex: "homepage_top:0.5"
+	Placements map[string]float64
+}
+
+// GeoIPConfig contains GeoIP database configuration
+type GeoIPConfig struct {
+	// DatabasePath is a CSV file of "network,country,region,city" lines, geo is not derived from IPs when empty
+	DatabasePath string `split_words:"true"`
+}
+
+// SegmentConfig contains audience segment store configuration
+type SegmentConfig struct {
+	// DefaultTTL is the lifetime of memberships uploaded without a TTL
+	DefaultTTL time.Duration `default:"720h" split_words:"true"`
+	// ExpiryInterval is how often expired memberships are deleted
+	ExpiryInterval time.Duration `default:"1m" split_words:"true"`
+}
+
+// OpenRTBConfig contains OpenRTB auction configuration
+type OpenRTBConfig struct {
+	// NoticeBaseURL is the externally reachable URL of the service used in win and billing notice URLs
+	NoticeBaseURL string `default:"http://localhost:8080" split_words:"true"`
+	// BidTTL is how long bids wait for their win notice, before they expire and their reservation is released
+	BidTTL time.Duration `default:"1h" split_words:"true"`
+	// BillingTTL is how long won bids are kept for their billing notice
+	BillingTTL     time.Duration `default:"24h" split_words:"true"`
+	ExpiryInterval time.Duration `default:"1m" split_words:"true"`
+}
+
+// VASTConfig contains video ad configuration
+type VASTConfig struct {
+	// Placements are the video placements, which only serve video creatives and
+	// return VAST documents
+	Placements []string
+	// TrackingBaseURL is the externally reachable URL of the service used in VAST tracking URLs
+	TrackingBaseURL string `default:"http://localhost:8080" split_words:"true"`
+}
+
+// DealConfig contains private marketplace deal configuration
+type DealConfig struct {
+	// PriorityTiers are the deal price types in priority order, ahead of the open auction
+	PriorityTiers []string `default:"fixed,floor" split_words:"true"`
+}
+
+// DeliveryConfig contains guaranteed delivery pacing and underdelivery alert configuration
+type DeliveryConfig struct {
+	// MaxBoost caps the ranking boost of guaranteed line items behind schedule
+	MaxBoost float64 `default:"4" split_words:"true"`
+	// UnderdeliveryTolerance is the share of the impression goal a forecast may miss before alerting
+	UnderdeliveryTolerance float64 `default:"0.1" split_words:"true"`
+	// ForecastWarmup is the part of the flight delivered before delivery is forecast
+	ForecastWarmup time.Duration `default:"1h" split_words:"true"`
+	// AlertInterval is how often guaranteed line items are checked for underdelivery
+	AlertInterval time.Duration `default:"5m" split_words:"true"`
+	// WebhookURL receives underdelivery alerts, alerts are only logged when empty
+	WebhookURL     string        `split_words:"true"`
+	WebhookTimeout time.Duration `default:"5s" split_words:"true"`
+}
+
+// ForecastConfig contains inventory forecast configuration
+type ForecastConfig struct {
+	// Lookback is how many hours of ad request counts are kept and forecast from
+	Lookback time.Duration `default:"672h"`
+	// ExpiryInterval is how often counts older than Lookback are deleted
+	ExpiryInterval time.Duration `default:"1h" split_words:"true"`
+}
+
+// RequestLogConfig contains ad request log configuration
+type RequestLogConfig struct {
+	// SampleRate is the share of ad requests logged, between 0 and 1
+	SampleRate float64 `default:"0.01" split_words:"true"`
+	// QueueSize is the number of logged requests queued for writing, further requests are dropped
+	QueueSize int `default:"10000" split_words:"true"`
+	// Path is the NDJSON request log file, logged requests are only kept in memory when empty
+	Path           string        `split_words:"true"`
+	RotateMaxBytes int64         `default:"104857600" split_words:"true"`
+	RotateMaxAge   time.Duration `default:"1h" split_words:"true"`
+}
+
 // Load loads the configuration from environment variables
 func Load() (*Config, error) {
 	var config Config
@@ -5,4 +5,35 @@ import "errors"
 var (
 	ErrLineItemNotFound       = errors.New("line item not found")
 	ErrLineItemAlreadyUpdated = errors.New("line item already updated")
+	ErrInvalidCursor          = errors.New("invalid cursor")
+	ErrLineItemInvalidFlight  = errors.New("line item end date must be after start date")
+
+	ErrAdvertiserNotFound       = errors.New("advertiser not found")
+	ErrAdvertiserSuspended      = errors.New("advertiser suspended")
+	ErrAdvertiserBudgetExceeded = errors.New("advertiser budget cap exceeded")
+
+	ErrCampaignNotFound           = errors.New("campaign not found")
+	ErrCampaignAdvertiserMismatch = errors.New("campaign belongs to another advertiser")
+	ErrCampaignBudgetExceeded     = errors.New("campaign budget exceeded")
+	ErrCampaignInvalidFlight      = errors.New("campaign end date must be after start date")
+
+	ErrCreativeNotFound           = errors.New("creative not found")
+	ErrCreativeAdvertiserMismatch = errors.New("creative belongs to another advertiser")
+	ErrCreativeSizeMismatch       = errors.New("creative size is not allowed on placement")
+	ErrCreativeFormatMismatch     = errors.New("creative format is not allowed on placement")
+	ErrCreativePolicyViolation    = errors.New("creative violates ad policy")
+
+	ErrAudienceNotFound           = errors.New("audience not found")
+	ErrAudienceAdvertiserMismatch = errors.New("audience belongs to another advertiser")
+	ErrLineItemAdvertiserMismatch = errors.New("line item belongs to another advertiser")
+
+	ErrDealNotFound      = errors.New("deal not found")
+	ErrDealAlreadyExists = errors.New("deal already exists")
+	ErrDealNotAllowed    = errors.New("deal does not allow the advertiser or placement")
+	ErrDealBidBelowFloor = errors.New("bid is below the deal floor price")
+
+	ErrForecastFlightTooLong = errors.New("forecast flight is longer than a year")
+
+	ErrBidNotFound       = errors.New("bid not found")
+	ErrBidStatusConflict = errors.New("bid status does not allow the notice")
 )
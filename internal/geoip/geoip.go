@@ -0,0 +1,109 @@
+// Package geoip resolves IP addresses to locations from a local CSV database.
+//
+// Every line of the database maps a network to a location:
+//
+//	network,country,region,city
+//	81.2.69.0/24,GB,ENG,London
+//
+// Lines starting with "#" and a "network" header line are ignored. Nested
+// networks are allowed, the most specific network wins.
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"sweng-task/internal/model"
+)
+
+// Database is an in-memory GeoIP database
+type Database struct {
+	networks map[netip.Prefix]model.Geo
+	// bits are the distinct prefix lengths, longest first
+	bits []int
+}
+
+// Open loads the database at path
+func Open(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load reads a database in CSV format
+func Load(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	db := &Database{networks: make(map[netip.Prefix]model.Geo)}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 0 || record[0] == "network" {
+			continue
+		}
+		if len(record) < 2 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("geoip: line %d: expected network and country", line)
+		}
+		prefix, err := netip.ParsePrefix(record[0])
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("geoip: line %d: %w", line, err)
+		}
+		geo := model.Geo{Country: strings.ToUpper(record[1])}
+		if len(record) > 2 {
+			geo.Region = record[2]
+		}
+		if len(record) > 3 {
+			geo.City = record[3]
+		}
+		prefix = prefix.Masked()
+		db.networks[prefix] = geo
+		if !slices.Contains(db.bits, prefix.Bits()) {
+			db.bits = append(db.bits, prefix.Bits())
+		}
+	}
+	slices.Sort(db.bits)
+	slices.Reverse(db.bits)
+	return db, nil
+}
+
+// Lookup returns the location of the most specific network containing ip
+func (db *Database) Lookup(ip netip.Addr) (model.Geo, bool) {
+	ip = ip.Unmap()
+	for _, bits := range db.bits {
+		if bits > ip.BitLen() {
+			continue
+		}
+		prefix, err := ip.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if geo, ok := db.networks[prefix]; ok {
+			return geo, true
+		}
+	}
+	return model.Geo{}, false
+}
+
+// Len returns the number of networks in the database
+func (db *Database) Len() int {
+	return len(db.networks)
+}
@@ -0,0 +1,47 @@
+package geoip
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"sweng-task/internal/model"
+)
+
+func TestDatabase_Lookup(t *testing.T) {
+	db, err := Load(strings.NewReader(`network,country,region,city
+# nested networks, the most specific wins
+81.2.0.0/16,gb
+81.2.69.0/24,GB,ENG,London
+2001:db8::/32,DE,BE,Berlin
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		ip     string
+		want   model.Geo
+		wantOK bool
+	}{
+		{"81.2.69.160", model.Geo{Country: "GB", Region: "ENG", City: "London"}, true},
+		{"81.2.1.1", model.Geo{Country: "GB"}, true},
+		{"::ffff:81.2.69.1", model.Geo{Country: "GB", Region: "ENG", City: "London"}, true},
+		{"2001:db8::1", model.Geo{Country: "DE", Region: "BE", City: "Berlin"}, true},
+		{"10.0.0.1", model.Geo{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got, ok := db.Lookup(netip.MustParseAddr(tt.ip))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Lookup() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoad_InvalidNetwork(t *testing.T) {
+	if _, err := Load(strings.NewReader("81.2.69.0/33,GB\n")); err == nil {
+		t.Error("Load() error = nil, want invalid network error")
+	}
+}
@@ -1,23 +1,33 @@
 package handler
 
 import (
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 
+	"sweng-task/internal/model"
 	"sweng-task/internal/service"
+	"sweng-task/internal/vast"
 )
 
 type AdHandler struct {
 	service *service.AdService
-	log     *zap.SugaredLogger
+	// videoPlacements return VAST documents, whose trackers point to trackingBaseURL
+	videoPlacements []string
+	trackingBaseURL string
+	log             *zap.SugaredLogger
 }
 
-func NewAdHandler(service *service.AdService, log *zap.SugaredLogger) *AdHandler {
+func NewAdHandler(service *service.AdService, videoPlacements []string, trackingBaseURL string, log *zap.SugaredLogger) *AdHandler {
 	return &AdHandler{
-		service: service,
-		log:     log,
+		service:         service,
+		videoPlacements: videoPlacements,
+		trackingBaseURL: strings.TrimSuffix(trackingBaseURL, "/"),
+		log:             log,
 	}
 }
 
@@ -32,6 +42,26 @@ func (h *AdHandler) GetWinningAds(c *fiber.Ctx) error {
 	}
 	category := c.Query("category")
 	keyword := c.Query("keyword")
+	domain := c.Query("domain")
+	geo := model.Geo{
+		Country: strings.ToUpper(c.Query("country")),
+		Region:  c.Query("region"),
+		City:    c.Query("city"),
+	}
+	device := model.Device{
+		Type:    model.DeviceType(c.Query("device_type")),
+		OS:      c.Query("os"),
+		Browser: c.Query("browser"),
+	}
+	switch device.Type {
+	case "", model.DeviceTypeDesktop, model.DeviceTypeMobile, model.DeviceTypeTablet, model.DeviceTypeCTV:
+	default:
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "device_type should be one of desktop, mobile, tablet, ctv",
+			})
+	}
 	limitStr := c.Query("limit", "1")
 	limit, err := strconv.Atoi(limitStr)
 	if err == nil && (limit < 0 || limit > 10) {
@@ -42,11 +72,26 @@ func (h *AdHandler) GetWinningAds(c *fiber.Ctx) error {
 			})
 	}
 
+	video := slices.Contains(h.videoPlacements, placement)
+	var formats []model.CreativeFormat
+	if video {
+		formats = []model.CreativeFormat{model.CreativeFormatVideo}
+	}
+	userID := c.Query("user_id")
+
 	ads, err := h.service.GetWinningAds(service.AdQuery{
+		Formats:   formats,
 		Placement: placement,
 		Category:  category,
 		Keyword:   keyword,
+		Domain:    domain,
 		Limit:     limit,
+		Geo:       geo,
+		Device:    device,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		UserID:    userID,
+		DealIDs:   splitQueryList(c.Query("deal_ids")),
 	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).
@@ -56,5 +101,34 @@ func (h *AdHandler) GetWinningAds(c *fiber.Ctx) error {
 				"details": err.Error(),
 			})
 	}
+	if video {
+		return h.vast(c, ads, placement, userID)
+	}
 	return c.Status(fiber.StatusOK).JSON(ads)
 }
+
+// vast responds with the VAST document of the ads of a video placement
+func (h *AdHandler) vast(c *fiber.Ctx, ads []*model.Ad, placement, userID string) error {
+	body, err := vast.Build(ads, func(ad *model.Ad, eventType model.TrackingEventType) string {
+		query := url.Values{
+			"event_type":   {string(eventType)},
+			"line_item_id": {ad.ID},
+			"creative_id":  {ad.Creative.ID},
+			"placement":    {placement},
+		}
+		if userID != "" {
+			query.Set("user_id", userID)
+		}
+		return h.trackingBaseURL + "/api/v1/tracking/pixel?" + query.Encode()
+	}).Marshal()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "failed to build VAST document",
+				"details": err.Error(),
+			})
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return c.Status(fiber.StatusOK).Send(body)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+// AdvertiserHandler handles HTTP requests related to advertisers
+type AdvertiserHandler struct {
+	service  *service.AdvertiserService
+	log      *zap.SugaredLogger
+	validate *validator.Validate
+}
+
+// NewAdvertiserHandler creates a new AdvertiserHandler
+func NewAdvertiserHandler(service *service.AdvertiserService, validate *validator.Validate, log *zap.SugaredLogger) *AdvertiserHandler {
+	return &AdvertiserHandler{
+		service:  service,
+		validate: validate,
+		log:      log,
+	}
+}
+
+// Create handles the creation of a new advertiser
+func (h *AdvertiserHandler) Create(c *fiber.Ctx) error {
+	var input model.AdvertiserCreate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	advertiser, err := h.service.Create(input)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to create advertiser",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(advertiser)
+}
+
+// GetByID handles retrieving an advertiser by ID
+func (h *AdvertiserHandler) GetByID(c *fiber.Ctx) error {
+	advertiser, err := h.service.GetByID(c.Params("id"))
+	if err != nil {
+		return h.error(c, err, "Failed to retrieve advertiser")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(advertiser)
+}
+
+// GetAll handles retrieving all advertisers with optional status filtering
+func (h *AdvertiserHandler) GetAll(c *fiber.Ctx) error {
+	advertisers, err := h.service.GetAll(model.AdvertiserStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve advertisers",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(advertisers)
+}
+
+// Update handles partial updates of an advertiser, including suspension
+func (h *AdvertiserHandler) Update(c *fiber.Ctx) error {
+	var input model.AdvertiserUpdate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	advertiser, err := h.service.Update(c.Params("id"), input)
+	if err != nil {
+		return h.error(c, err, "Failed to update advertiser")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(advertiser)
+}
+
+// UpdateBlocklist handles replacing the blocklist of an advertiser
+func (h *AdvertiserHandler) UpdateBlocklist(c *fiber.Ctx) error {
+	var input model.Blocklist
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	advertiser, err := h.service.UpdateBlocklist(c.Params("id"), input)
+	if err != nil {
+		return h.error(c, err, "Failed to update advertiser blocklist")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(advertiser)
+}
+
+func (h *AdvertiserHandler) error(c *fiber.Ctx, err error, message string) error {
+	if errors.Is(err, domain_errors.ErrAdvertiserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"code":    fiber.StatusNotFound,
+			"message": "Advertiser not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"code":    fiber.StatusInternalServerError,
+		"message": message,
+		"details": err.Error(),
+	})
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+// AudienceHandler handles HTTP requests related to retargeting audiences
+type AudienceHandler struct {
+	service  *service.AudienceService
+	log      *zap.SugaredLogger
+	validate *validator.Validate
+}
+
+// NewAudienceHandler creates a new AudienceHandler
+func NewAudienceHandler(service *service.AudienceService, validate *validator.Validate, log *zap.SugaredLogger) *AudienceHandler {
+	return &AudienceHandler{
+		service:  service,
+		validate: validate,
+		log:      log,
+	}
+}
+
+// Create handles the creation of a new audience
+func (h *AudienceHandler) Create(c *fiber.Ctx) error {
+	var input model.AudienceCreate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	audience, err := h.service.Create(input)
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrAdvertiserNotFound) || errors.Is(err, domain_errors.ErrAdvertiserSuspended) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid advertiser",
+				"details": err.Error(),
+			})
+		}
+		if errors.Is(err, domain_errors.ErrLineItemNotFound) || errors.Is(err, domain_errors.ErrLineItemAdvertiserMismatch) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid line item",
+				"details": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to create audience",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(audience)
+}
+
+// GetByID handles retrieving an audience by ID
+func (h *AudienceHandler) GetByID(c *fiber.Ctx) error {
+	audience, err := h.service.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrAudienceNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "Audience not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve audience",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(audience)
+}
+
+// GetAll handles retrieving all audiences with optional filtering by advertiser
+func (h *AudienceHandler) GetAll(c *fiber.Ctx) error {
+	audiences, err := h.service.GetAll(c.Query("advertiser_id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve audiences",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(audiences)
+}
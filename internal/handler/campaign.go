@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+// CampaignHandler handles HTTP requests related to campaigns
+type CampaignHandler struct {
+	service  *service.CampaignService
+	log      *zap.SugaredLogger
+	validate *validator.Validate
+}
+
+// NewCampaignHandler creates a new CampaignHandler
+func NewCampaignHandler(service *service.CampaignService, validate *validator.Validate, log *zap.SugaredLogger) *CampaignHandler {
+	return &CampaignHandler{
+		service:  service,
+		validate: validate,
+		log:      log,
+	}
+}
+
+// Create handles the creation of a new campaign
+func (h *CampaignHandler) Create(c *fiber.Ctx) error {
+	var input model.CampaignCreate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	campaign, err := h.service.Create(input)
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrAdvertiserNotFound) || errors.Is(err, domain_errors.ErrAdvertiserSuspended) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid advertiser",
+				"details": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to create campaign",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(campaign)
+}
+
+// GetByID handles retrieving a campaign by ID
+func (h *CampaignHandler) GetByID(c *fiber.Ctx) error {
+	campaign, err := h.service.GetByID(c.Params("id"))
+	if err != nil {
+		return h.error(c, err, "Failed to retrieve campaign")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(campaign)
+}
+
+// GetAll handles retrieving all campaigns with optional filtering
+func (h *CampaignHandler) GetAll(c *fiber.Ctx) error {
+	campaigns, err := h.service.GetAll(c.Query("advertiser_id"), model.CampaignStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve campaigns",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(campaigns)
+}
+
+// Update handles partial updates of a campaign, including pausing
+func (h *CampaignHandler) Update(c *fiber.Ctx) error {
+	var input model.CampaignUpdate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	campaign, err := h.service.Update(c.Params("id"), input)
+	if err != nil {
+		return h.error(c, err, "Failed to update campaign")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(campaign)
+}
+
+func (h *CampaignHandler) error(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, domain_errors.ErrCampaignNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"code":    fiber.StatusNotFound,
+			"message": "Campaign not found",
+		})
+	case errors.Is(err, domain_errors.ErrCampaignInvalidFlight):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"code":    fiber.StatusInternalServerError,
+		"message": message,
+		"details": err.Error(),
+	})
+}
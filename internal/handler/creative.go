@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+// CreativeHandler handles HTTP requests related to creatives
+type CreativeHandler struct {
+	service  *service.CreativeService
+	log      *zap.SugaredLogger
+	validate *validator.Validate
+}
+
+// NewCreativeHandler creates a new CreativeHandler
+func NewCreativeHandler(service *service.CreativeService, validate *validator.Validate, log *zap.SugaredLogger) *CreativeHandler {
+	return &CreativeHandler{
+		service:  service,
+		validate: validate,
+		log:      log,
+	}
+}
+
+// Create handles the creation of a new creative
+func (h *CreativeHandler) Create(c *fiber.Ctx) error {
+	var input model.CreativeCreate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	creative, err := h.service.Create(input)
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrAdvertiserNotFound) || errors.Is(err, domain_errors.ErrAdvertiserSuspended) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid advertiser",
+				"details": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to create creative",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(creative)
+}
+
+// GetByID handles retrieving a creative by ID
+func (h *CreativeHandler) GetByID(c *fiber.Ctx) error {
+	creative, err := h.service.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrCreativeNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "Creative not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve creative",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(creative)
+}
+
+// GetAll handles retrieving all creatives with optional filtering
+func (h *CreativeHandler) GetAll(c *fiber.Ctx) error {
+	creatives, err := h.service.GetAll(c.Query("advertiser_id"), model.CreativeStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve creatives",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(creatives)
+}
+
+// Approve handles approving a creative in review
+func (h *CreativeHandler) Approve(c *fiber.Ctx) error {
+	creative, err := h.service.Approve(c.Params("id"))
+	if err != nil {
+		return h.reviewError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(creative)
+}
+
+// Reject handles rejecting a creative with a reason
+func (h *CreativeHandler) Reject(c *fiber.Ctx) error {
+	var input model.CreativeRejection
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	creative, err := h.service.Reject(c.Params("id"), input.Reason)
+	if err != nil {
+		return h.reviewError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(creative)
+}
+
+func (h *CreativeHandler) reviewError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain_errors.ErrCreativeNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"code":    fiber.StatusNotFound,
+			"message": "Creative not found",
+		})
+	case errors.Is(err, domain_errors.ErrCreativePolicyViolation):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"code":    fiber.StatusUnprocessableEntity,
+			"message": "Creative violates policy",
+			"details": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"code":    fiber.StatusInternalServerError,
+		"message": "Failed to review creative",
+		"details": err.Error(),
+	})
+}
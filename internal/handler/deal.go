@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+// DealHandler handles HTTP requests related to private marketplace deals
+type DealHandler struct {
+	service  *service.DealService
+	log      *zap.SugaredLogger
+	validate *validator.Validate
+}
+
+// NewDealHandler creates a new DealHandler
+func NewDealHandler(service *service.DealService, validate *validator.Validate, log *zap.SugaredLogger) *DealHandler {
+	return &DealHandler{
+		service:  service,
+		validate: validate,
+		log:      log,
+	}
+}
+
+// Create handles the creation of a new deal
+func (h *DealHandler) Create(c *fiber.Ctx) error {
+	var input model.DealCreate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	deal, err := h.service.Create(input)
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrDealAlreadyExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"code":    fiber.StatusConflict,
+				"message": "Deal already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to create deal",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(deal)
+}
+
+// GetByID handles retrieving a deal by ID
+func (h *DealHandler) GetByID(c *fiber.Ctx) error {
+	deal, err := h.service.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrDealNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "Deal not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve deal",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(deal)
+}
+
+// GetAll handles retrieving all deals
+func (h *DealHandler) GetAll(c *fiber.Ctx) error {
+	deals, err := h.service.GetAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve deals",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(deals)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/service"
+)
+
+// DeliveryHandler handles HTTP requests related to line item delivery goals
+type DeliveryHandler struct {
+	service *service.DeliveryService
+	log     *zap.SugaredLogger
+}
+
+// NewDeliveryHandler creates a new DeliveryHandler
+func NewDeliveryHandler(service *service.DeliveryService, log *zap.SugaredLogger) *DeliveryHandler {
+	return &DeliveryHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// GetByLineItemID handles retrieving the delivery of a line item
+func (h *DeliveryHandler) GetByLineItemID(c *fiber.Ctx) error {
+	delivery, err := h.service.GetByLineItemID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrLineItemNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "Line item not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve line item delivery",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(delivery)
+}
+
+// GetGuaranteed handles retrieving the delivery of all guaranteed line items
+func (h *DeliveryHandler) GetGuaranteed(c *fiber.Ctx) error {
+	deliveries, err := h.service.GetGuaranteed()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve line item delivery",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(deliveries)
+}
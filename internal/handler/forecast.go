@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+// ForecastHandler handles HTTP requests related to inventory forecasts
+type ForecastHandler struct {
+	service *service.ForecastService
+	log     *zap.SugaredLogger
+}
+
+// NewForecastHandler creates a new ForecastHandler
+func NewForecastHandler(service *service.ForecastService, log *zap.SugaredLogger) *ForecastHandler {
+	return &ForecastHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Forecast handles forecasting the inventory of proposed targeting
+func (h *ForecastHandler) Forecast(c *fiber.Ctx) error {
+	var input model.ForecastRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	forecast, err := h.service.Forecast(input)
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrForecastFlightTooLong) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+				"details": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to forecast inventory",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(forecast)
+}
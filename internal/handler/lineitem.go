@@ -49,6 +49,42 @@ func (h *LineItemHandler) Create(c *fiber.Ctx) error {
 
 	lineItem, err := h.service.Create(input)
 	if err != nil {
+		if errors.Is(err, domain_errors.ErrLineItemInvalidFlight) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+				"details": err.Error(),
+			})
+		}
+		if errors.Is(err, domain_errors.ErrAdvertiserNotFound) || errors.Is(err, domain_errors.ErrAdvertiserSuspended) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid advertiser",
+				"details": err.Error(),
+			})
+		}
+		if errors.Is(err, domain_errors.ErrCampaignNotFound) || errors.Is(err, domain_errors.ErrCampaignAdvertiserMismatch) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid campaign",
+				"details": err.Error(),
+			})
+		}
+		if errors.Is(err, domain_errors.ErrAudienceNotFound) || errors.Is(err, domain_errors.ErrAudienceAdvertiserMismatch) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid audience",
+				"details": err.Error(),
+			})
+		}
+		if errors.Is(err, domain_errors.ErrDealNotFound) || errors.Is(err, domain_errors.ErrDealNotAllowed) ||
+			errors.Is(err, domain_errors.ErrDealBidBelowFloor) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid deal",
+				"details": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"code":    fiber.StatusInternalServerError,
 			"message": "Failed to create line item",
@@ -103,3 +139,97 @@ func (h *LineItemHandler) GetAll(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(lineItems)
 }
+
+// AttachCreative handles attaching a creative to a line item's rotation
+func (h *LineItemHandler) AttachCreative(c *fiber.Ctx) error {
+	var input model.LineItemCreative
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	lineItem, err := h.service.AttachCreative(c.Params("id"), input)
+	if err != nil {
+		return h.creativeError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(lineItem)
+}
+
+// DetachCreative handles removing a creative from a line item's rotation
+func (h *LineItemHandler) DetachCreative(c *fiber.Ctx) error {
+	lineItem, err := h.service.DetachCreative(c.Params("id"), c.Params("creative_id"))
+	if err != nil {
+		return h.creativeError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(lineItem)
+}
+
+// UpdateBlocklist handles replacing the blocklist of a line item
+func (h *LineItemHandler) UpdateBlocklist(c *fiber.Ctx) error {
+	var input model.Blocklist
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	lineItem, err := h.service.UpdateBlocklist(c.Params("id"), input)
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrLineItemNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "Line item not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to update line item blocklist",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(lineItem)
+}
+
+func (h *LineItemHandler) creativeError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain_errors.ErrLineItemNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"code":    fiber.StatusNotFound,
+			"message": "Line item not found",
+		})
+	case errors.Is(err, domain_errors.ErrCreativeNotFound), errors.Is(err, domain_errors.ErrCreativeAdvertiserMismatch),
+		errors.Is(err, domain_errors.ErrCreativeSizeMismatch), errors.Is(err, domain_errors.ErrCreativeFormatMismatch):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid creative",
+			"details": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"code":    fiber.StatusInternalServerError,
+		"message": "Failed to update line item creatives",
+		"details": err.Error(),
+	})
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/openrtb"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+// auctionCurrency is the currency of bids and budgets
+const auctionCurrency = "USD"
+
+// OpenRTBHandler handles OpenRTB auctions and their win, billing and loss notices
+type OpenRTBHandler struct {
+	service *service.AuctionService
+	// noticeBaseURL is the externally reachable URL of the service used in notice URLs
+	noticeBaseURL string
+	log           *zap.SugaredLogger
+}
+
+// NewOpenRTBHandler creates a new OpenRTBHandler
+func NewOpenRTBHandler(service *service.AuctionService, noticeBaseURL string, log *zap.SugaredLogger) *OpenRTBHandler {
+	return &OpenRTBHandler{
+		service:       service,
+		noticeBaseURL: strings.TrimSuffix(noticeBaseURL, "/"),
+		log:           log,
+	}
+}
+
+// Auction handles an OpenRTB 2.6 bid request, bidding at most once per impression.
+// The impression tagid is the placement. Requests without bids are answered
+// with 204 No Content.
+func (h *OpenRTBHandler) Auction(c *fiber.Ctx) error {
+	return h.auction(c, func(imp *openrtb.Imp) string {
+		return imp.TagID
+	})
+}
+
+// Prebid handles a bid request of Prebid Server, where every impression is an
+// ad unit whose bidder parameters name the placement. Ad units without a
+// placement parameter fall back to their tagid.
+func (h *OpenRTBHandler) Prebid(c *fiber.Ctx) error {
+	return h.auction(c, func(imp *openrtb.Imp) string {
+		if placement := imp.BidderParams().Placement; placement != "" {
+			return placement
+		}
+		return imp.TagID
+	})
+}
+
+func (h *OpenRTBHandler) auction(c *fiber.Ctx, placementOf func(imp *openrtb.Imp) string) error {
+	var req openrtb.BidRequest
+	if err := c.BodyParser(&req); err != nil {
+		return h.noBid(c, fiber.StatusBadRequest, "", openrtb.NoBidInvalidRequest)
+	}
+	if err := validation.Validate(&req); err != nil {
+		return h.noBid(c, fiber.StatusBadRequest, req.ID, openrtb.NoBidInvalidRequest)
+	}
+	if len(req.Cur) > 0 && !slices.ContainsFunc(req.Cur, func(cur string) bool { return strings.EqualFold(cur, auctionCurrency) }) {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	var bids []openrtb.Bid
+	for i := range req.Imp {
+		imp := &req.Imp[i]
+		placement := placementOf(imp)
+		if placement == "" || imp.Banner == nil && imp.Native == nil ||
+			imp.BidFloorCur != "" && !strings.EqualFold(imp.BidFloorCur, auctionCurrency) {
+			continue
+		}
+		auctionBids, err := h.service.Bid(req.ID, imp.ID, imp.BidFloor, adQueryFromBidRequest(&req, imp, placement))
+		if err != nil {
+			h.log.Errorw("error in bidding on impression",
+				"request_id", req.ID,
+				"imp_id", imp.ID,
+				"error", err)
+			return h.noBid(c, fiber.StatusInternalServerError, req.ID, openrtb.NoBidTechnicalError)
+		}
+		for _, bid := range auctionBids {
+			bids = append(bids, h.bid(bid))
+		}
+	}
+	if len(bids) == 0 {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(openrtb.BidResponse{
+		ID:      req.ID,
+		SeatBid: []openrtb.SeatBid{{Bid: bids}},
+		Cur:     auctionCurrency,
+	})
+}
+
+// Win handles the win notice of a bid
+func (h *OpenRTBHandler) Win(c *fiber.Ctx) error {
+	bid, err := h.service.Win(c.Params("bid_id"), h.auctionPrice(c))
+	if err != nil {
+		return h.noticeError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(bid)
+}
+
+// Bill handles the billing notice of a bid
+func (h *OpenRTBHandler) Bill(c *fiber.Ctx) error {
+	bid, err := h.service.Bill(c.Params("bid_id"), h.auctionPrice(c))
+	if err != nil {
+		return h.noticeError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(bid)
+}
+
+// Loss handles the loss notice of a bid, which releases its reservation
+func (h *OpenRTBHandler) Loss(c *fiber.Ctx) error {
+	reason, err := strconv.Atoi(c.Query("reason"))
+	if err != nil {
+		// unsubstituted macro or no reason
+		reason = 0
+	}
+	bid, err := h.service.Loss(c.Params("bid_id"), reason)
+	if err != nil {
+		return h.noticeError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(bid)
+}
+
+func (h *OpenRTBHandler) bid(bid *service.AuctionBid) openrtb.Bid {
+	notices := newNoticeURLs(h.noticeBaseURL, bid.ID)
+	result := openrtb.Bid{
+		ID:     bid.ID,
+		ImpID:  bid.ImpID,
+		Price:  bid.Price,
+		NURL:   notices.win,
+		BURL:   notices.bill,
+		LURL:   notices.loss,
+		AdID:   bid.LineItemID,
+		CID:    bid.CampaignID,
+		DealID: bid.DealID,
+	}
+	if bid.AdvertiserDomain != "" {
+		result.ADomain = []string{bid.AdvertiserDomain}
+	}
+	if creative := bid.Ad.Creative; creative != nil {
+		result.CrID = creative.ID
+		result.AdM = openrtb.Markup(creative)
+		result.MType = openrtb.MarkupBanner
+		mediaType := "banner"
+		if creative.Format == model.CreativeFormatNative {
+			result.MType = openrtb.MarkupNative
+			mediaType = "native"
+		} else {
+			result.W, result.H = creative.Width, creative.Height
+		}
+		result.Ext = &openrtb.BidExt{Prebid: openrtb.BidExtPrebid{Type: mediaType}}
+	}
+	return result
+}
+
+// auctionPrice returns the CPM clearing price of a notice, or nil when the
+// exchange did not substitute the price macro or sent an unreadable price
+func (h *OpenRTBHandler) auctionPrice(c *fiber.Ctx) *float64 {
+	raw := c.Query("price")
+	if raw == "" || raw == openrtb.AuctionPriceMacro {
+		return nil
+	}
+	price, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		h.log.Warnw("unreadable auction price in notice, using bid price",
+			"bid_id", c.Params("bid_id"),
+			"price", raw)
+		return nil
+	}
+	return &price
+}
+
+// noticeURLs are the notice URLs of a bid with the macros substituted by the exchange
+type noticeURLs struct {
+	win, bill, loss string
+}
+
+func newNoticeURLs(baseURL, bidID string) noticeURLs {
+	priceQuery := "?price=" + openrtb.AuctionPriceMacro
+	return noticeURLs{
+		win:  baseURL + "/openrtb2/win/" + bidID + priceQuery,
+		bill: baseURL + "/openrtb2/bill/" + bidID + priceQuery,
+		loss: baseURL + "/openrtb2/loss/" + bidID + "?reason=" + openrtb.AuctionLossMacro,
+	}
+}
+
+func (h *OpenRTBHandler) noBid(c *fiber.Ctx, status int, requestID string, reason int) error {
+	return c.Status(status).JSON(openrtb.BidResponse{ID: requestID, NBR: &reason})
+}
+
+func (h *OpenRTBHandler) noticeError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, domain_errors.ErrBidNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"code":    fiber.StatusNotFound,
+			"message": "Bid not found",
+		})
+	}
+	if errors.Is(err, domain_errors.ErrBidStatusConflict) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"code":    fiber.StatusConflict,
+			"message": "Bid already settled",
+			"details": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"code":    fiber.StatusInternalServerError,
+		"message": "Failed to process notice",
+		"details": err.Error(),
+	})
+}
+
+// adQueryFromBidRequest maps an impression and the site or app, device and user
+// of its bid request to an ad query for one ad on the placement
+func adQueryFromBidRequest(req *openrtb.BidRequest, imp *openrtb.Imp, placement string) service.AdQuery {
+	q := service.AdQuery{
+		Placement:                   placement,
+		Limit:                       1,
+		BlockedAdvertiserDomains:    req.BAdv,
+		BlockedAdvertiserCategories: req.BCat,
+	}
+	var categories []string
+	var keywords string
+	switch {
+	case req.Site != nil:
+		q.Domain = req.Site.Domain
+		categories, keywords = req.Site.Cat, req.Site.Keywords
+	case req.App != nil:
+		q.Domain = req.App.Domain
+		categories, keywords = req.App.Cat, req.App.Keywords
+	}
+	if len(categories) > 0 {
+		q.Category = categories[0]
+	}
+	if keyword, _, _ := strings.Cut(keywords, ","); keyword != "" {
+		q.Keyword = strings.TrimSpace(keyword)
+	}
+
+	var geo *openrtb.Geo
+	if device := req.Device; device != nil {
+		q.IP = device.IP
+		if q.IP == "" {
+			q.IP = device.IPv6
+		}
+		q.UserAgent = device.UA
+		q.Device = model.Device{Type: device.Type(), OS: device.OS}
+		geo = device.Geo
+	}
+	if user := req.User; user != nil {
+		q.UserID = user.BuyerUID
+		if q.UserID == "" {
+			q.UserID = user.ID
+		}
+		if geo == nil {
+			geo = user.Geo
+		}
+	}
+	if geo != nil {
+		q.Geo = model.Geo{Country: geo.CountryAlpha2(), Region: geo.RegionCode(), City: geo.City}
+	}
+	if imp.Banner != nil {
+		q.Formats = append(q.Formats, model.CreativeFormatImage, model.CreativeFormatHTML)
+		q.Sizes = imp.Banner.Sizes()
+	}
+	if imp.Native != nil {
+		q.Formats = append(q.Formats, model.CreativeFormatNative)
+	}
+	if pmp := imp.PMP; pmp != nil {
+		for _, deal := range pmp.Deals {
+			q.DealIDs = append(q.DealIDs, deal.ID)
+		}
+		q.PrivateAuction = pmp.PrivateAuction == 1
+	}
+	return q
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseTimeRange parses the optional RFC3339 "from" and "to" query parameters
+func parseTimeRange(c *fiber.Ctx) (from, to time.Time, err error) {
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("from should be a RFC3339 timestamp: %w", err)
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("to should be a RFC3339 timestamp: %w", err)
+		}
+	}
+	return from, to, nil
+}
+
+// splitQueryList splits a comma separated query parameter, skipping empty values
+func splitQueryList(v string) []string {
+	var values []string
+	for _, value := range strings.Split(v, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+)
+
+type ReportHandler struct {
+	service *service.ReportService
+	log     *zap.SugaredLogger
+}
+
+func NewReportHandler(service *service.ReportService, log *zap.SugaredLogger) *ReportHandler {
+	return &ReportHandler{service: service, log: log}
+}
+
+// GetReport handles line item performance reports in JSON or CSV format
+func (h *ReportHandler) GetReport(c *fiber.Ctx) error {
+	q := service.ReportQuery{
+		Interval:     model.ReportInterval(c.Query("interval")),
+		LineItemID:   c.Query("line_item_id"),
+		AdvertiserID: c.Query("advertiser_id"),
+	}
+	for _, d := range strings.Split(c.Query("group_by", "line_item"), ",") {
+		dimension := model.ReportDimension(strings.TrimSpace(d))
+		switch dimension {
+		case model.ReportDimensionLineItem, model.ReportDimensionAdvertiser, model.ReportDimensionPlacement:
+			q.GroupBy = append(q.GroupBy, dimension)
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "group_by should be a comma separated list of line_item, advertiser, placement",
+			})
+		}
+	}
+	switch q.Interval {
+	case model.ReportIntervalNone, model.ReportIntervalHour, model.ReportIntervalDay:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "interval should be one of hour, day",
+		})
+	}
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "format should be one of json, csv",
+		})
+	}
+	var err error
+	if q.From, q.To, err = parseTimeRange(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid time range",
+			"details": err.Error(),
+		})
+	}
+
+	rows, err := h.service.Generate(q)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to generate report",
+			"details": err.Error(),
+		})
+	}
+
+	if format == "csv" {
+		body, err := reportCSV(rows)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "Failed to encode report",
+				"details": err.Error(),
+			})
+		}
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="report.csv"`)
+		return c.Status(fiber.StatusOK).Send(body)
+	}
+	return c.Status(fiber.StatusOK).JSON(rows)
+}
+
+var reportCSVHeader = []string{
+	"period", "line_item_id", "advertiser_id", "placement",
+	"impressions", "clicks", "conversions", "ctr", "cvr", "spend", "ecpm", "revenue", "roas", "remaining_budget",
+}
+
+func reportCSV(rows []*model.ReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(reportCSVHeader); err != nil {
+		return nil, err
+	}
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	for _, row := range rows {
+		period := ""
+		if row.Period != nil {
+			period = row.Period.Format(time.RFC3339)
+		}
+		err := w.Write([]string{
+			period,
+			row.LineItemID,
+			row.AdvertiserID,
+			row.Placement,
+			strconv.FormatInt(row.Impressions, 10),
+			strconv.FormatInt(row.Clicks, 10),
+			strconv.FormatInt(row.Conversions, 10),
+			formatFloat(row.CTR),
+			formatFloat(row.CVR),
+			formatFloat(row.Spend),
+			formatFloat(row.ECPM),
+			formatFloat(row.Revenue),
+			formatFloat(row.ROAS),
+			formatFloat(row.RemainingBudget),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+// SegmentHandler handles HTTP requests related to audience segments
+type SegmentHandler struct {
+	service  *service.SegmentService
+	log      *zap.SugaredLogger
+	validate *validator.Validate
+}
+
+// NewSegmentHandler creates a new SegmentHandler
+func NewSegmentHandler(service *service.SegmentService, validate *validator.Validate, log *zap.SugaredLogger) *SegmentHandler {
+	return &SegmentHandler{
+		service:  service,
+		validate: validate,
+		log:      log,
+	}
+}
+
+// Upload handles bulk uploads of segment memberships
+func (h *SegmentHandler) Upload(c *fiber.Ctx) error {
+	var input model.SegmentUpload
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if err := validation.Validate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	result, err := h.service.Upload(input)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to upload segment memberships",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// GetUserSegments handles retrieving the unexpired segment memberships of a user
+func (h *SegmentHandler) GetUserSegments(c *fiber.Ctx) error {
+	memberships, err := h.service.GetUserMemberships(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve user segments",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(memberships)
+}
+
+// RemoveMembership handles removing a user from a segment
+func (h *SegmentHandler) RemoveMembership(c *fiber.Ctx) error {
+	if err := h.service.RemoveMembership(c.Params("id"), c.Params("segment_id")); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to remove segment membership",
+			"details": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
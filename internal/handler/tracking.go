@@ -1,15 +1,22 @@
 package handler
 
 import (
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 
+	"sweng-task/internal/domain_errors"
 	"sweng-task/internal/model"
 	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
 )
 
+// trackingEventTypes lists the accepted event types in error messages
+const trackingEventTypes = "impression, click, conversion, video_start, video_first_quartile, video_midpoint, video_third_quartile, video_complete"
+
 type TrackingHandler struct {
 	service *service.TrackingService
 	log     *zap.SugaredLogger
@@ -28,9 +35,19 @@ func (h *TrackingHandler) TrackEvent(c *fiber.Ctx) error {
 			"details": err.Error(),
 		})
 	}
-	if event.Timestamp.IsZero() {
-		event.Timestamp = time.Now()
+	if err := validation.Validate(&event); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
 	}
+	// the event is timed when it is received and the client is always
+	// identified by the connection, so the invalid traffic filter cannot be
+	// bypassed through the request body
+	event.Timestamp = time.Now()
+	event.IP = c.IP()
+	event.UserAgent = c.Get(fiber.HeaderUserAgent)
 	err := h.service.Track(&event)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).
@@ -42,3 +59,136 @@ func (h *TrackingHandler) TrackEvent(c *fiber.Ctx) error {
 	}
 	return c.JSON(fiber.Map{"success": true})
 }
+
+// TrackPixel handles tracking events fired as GET requests by players and
+// browsers, such as VAST trackers, and answers with a transparent pixel.
+// Conversions are not accepted, since they carry order values.
+func (h *TrackingHandler) TrackPixel(c *fiber.Ctx) error {
+	event := model.TrackingEvent{
+		EventType:  model.TrackingEventType(c.Query("event_type")),
+		LineItemID: c.Query("line_item_id"),
+		CreativeID: c.Query("creative_id"),
+		Placement:  c.Query("placement"),
+		UserID:     c.Query("user_id"),
+		Timestamp:  time.Now(),
+		IP:         c.IP(),
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+	}
+	if !model.IsTrackingEventType(event.EventType) || event.EventType == model.TrackingEventTypeConversion {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "event_type should be one of impression, click or a video event",
+		})
+	}
+	if event.LineItemID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "line_item_id is required query string",
+		})
+	}
+	if err := h.service.Track(&event); err != nil {
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "failed to consume tracking event",
+				"details": err.Error(),
+			})
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderContentType, "image/gif")
+	return c.Send(transparentPixel)
+}
+
+// transparentPixel is a 1x1 transparent GIF
+var transparentPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// GetSinkStats returns delivery metrics of the tracking event sinks
+func (h *TrackingHandler) GetSinkStats(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.service.SinkStats())
+}
+
+// GetEvents returns stored tracking events matching the query filters, paginated by cursor
+func (h *TrackingHandler) GetEvents(c *fiber.Ctx) error {
+	q := service.TrackingEventQuery{
+		LineItemID: c.Query("line_item_id"),
+		EventType:  model.TrackingEventType(c.Query("event_type")),
+		Placement:  c.Query("placement"),
+		UserID:     c.Query("user_id"),
+		Cursor:     c.Query("cursor"),
+	}
+	if q.EventType != "" && !model.IsTrackingEventType(q.EventType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "event_type should be one of " + trackingEventTypes,
+		})
+	}
+
+	var err error
+	if q.From, q.To, err = parseTimeRange(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid time range",
+			"details": err.Error(),
+		})
+	}
+	q.Limit, err = strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || q.Limit < 1 || q.Limit > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "limit should be an integer between 1 and 500",
+		})
+	}
+
+	page, err := h.service.Query(q)
+	if err != nil {
+		if errors.Is(err, domain_errors.ErrInvalidCursor) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid cursor",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve tracking events",
+			"details": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// GetLineItemStats returns near-real-time event counts of a line item from rollup counters
+func (h *TrackingHandler) GetLineItemStats(c *fiber.Ctx) error {
+	q := service.LineItemStatsQuery{
+		LineItemID:  c.Params("id"),
+		Placement:   c.Query("placement"),
+		Granularity: model.RollupGranularity(c.Query("granularity", string(model.RollupGranularityHour))),
+	}
+	if q.Granularity != model.RollupGranularityMinute && q.Granularity != model.RollupGranularityHour {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "granularity should be one of minute, hour",
+		})
+	}
+	var err error
+	if q.From, q.To, err = parseTimeRange(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    fiber.StatusBadRequest,
+			"message": "Invalid time range",
+			"details": err.Error(),
+		})
+	}
+
+	stats, err := h.service.Stats(q)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"code":    fiber.StatusInternalServerError,
+			"message": "Failed to retrieve line item stats",
+			"details": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(stats)
+}
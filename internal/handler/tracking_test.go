@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+	"sweng-task/internal/service"
+	"sweng-task/internal/validation"
+)
+
+func TestTrackingHandler_TrackPixel(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	trackingService := service.NewTrackingService(repo.NewTrackingEventRepository(log), nil, nil, nil, nil, log)
+	app := fiber.New(fiber.Config{Immutable: true})
+	app.Get("/tracking/pixel", NewTrackingHandler(trackingService, log).TrackPixel)
+
+	eventTypes := []model.TrackingEventType{
+		model.TrackingEventTypeImpression,
+		model.TrackingEventTypeClick,
+		model.TrackingEventTypeVideoStart,
+		model.TrackingEventTypeVideoFirstQuartile,
+		model.TrackingEventTypeVideoMidpoint,
+		model.TrackingEventTypeVideoThirdQuartile,
+		model.TrackingEventTypeVideoComplete,
+	}
+	for _, eventType := range eventTypes {
+		t.Run(string(eventType), func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/tracking/pixel?line_item_id=li_1&event_type="+string(eventType), nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderContentType) != "image/gif" || !bytes.Equal(body, transparentPixel) {
+				t.Errorf("TrackPixel() = %d %q, %d bytes, want the transparent GIF", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), len(body))
+			}
+			page, err := trackingService.Query(service.TrackingEventQuery{LineItemID: "li_1", EventType: eventType})
+			if err != nil || len(page.Events) != 1 {
+				t.Errorf("tracked %v %s events, err = %v, want 1", len(page.Events), eventType, err)
+			}
+		})
+	}
+
+	stats, err := trackingService.Stats(service.LineItemStatsQuery{LineItemID: "li_1", Granularity: model.RollupGranularityHour})
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	want := model.VideoCounts{VideoStarts: 1, VideoFirstQuartiles: 1, VideoMidpoints: 1, VideoThirdQuartiles: 1, VideoCompletes: 1}
+	if stats.Impressions != 1 || stats.Clicks != 1 || stats.VideoCounts != want {
+		t.Errorf("Stats() = %+v, want one event of every type", stats)
+	}
+
+	for _, query := range []string{"line_item_id=li_1&event_type=conversion", "line_item_id=li_1&event_type=unknown", "event_type=impression"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/tracking/pixel?"+query, nil))
+		if err != nil {
+			t.Fatalf("app.Test() error = %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("TrackPixel(%s) status = %d, want %d", query, resp.StatusCode, fiber.StatusBadRequest)
+		}
+	}
+}
+
+func TestTrackingHandler_TrackEvent_ServerTime(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	trackingRepo := repo.NewTrackingEventRepository(log)
+	ivt := service.NewInvalidTrafficFilter(trackingRepo, service.InvalidTrafficRules{ImpressionLookback: time.Hour, MinClickDelay: time.Minute}, log)
+	trackingService := service.NewTrackingService(trackingRepo, nil, ivt, nil, nil, log)
+	validation.GetBaseValidator()
+	app := fiber.New(fiber.Config{Immutable: true})
+	app.Post("/tracking", NewTrackingHandler(trackingService, log).TrackEvent)
+
+	track := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodPost, "/tracking", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil || resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("TrackEvent(%s) = %v, %v, want 200", body, resp, err)
+		}
+	}
+	// the click claims to come an hour after the impression to pass the click delay
+	track(`{"event_type":"impression","line_item_id":"li_1"}`)
+	track(`{"event_type":"click","line_item_id":"li_1","timestamp":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`)
+
+	page, err := trackingService.Query(service.TrackingEventQuery{LineItemID: "li_1", EventType: model.TrackingEventTypeClick})
+	if err != nil || len(page.Events) != 1 {
+		t.Fatalf("Query() = %v, %v, want the click", page, err)
+	}
+	if click := page.Events[0]; click.InvalidReason != model.InvalidTrafficReasonFastClick || time.Since(click.Timestamp) > time.Minute {
+		t.Errorf("click = %s at %s, want a fast click timed when received", click.InvalidReason, click.Timestamp)
+	}
+}
+
+func TestTrackingHandler_TrackEvent_Validation(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	trackingService := service.NewTrackingService(repo.NewTrackingEventRepository(log), nil, nil, nil, nil, log)
+	validation.GetBaseValidator()
+	app := fiber.New(fiber.Config{Immutable: true})
+	app.Post("/tracking", NewTrackingHandler(trackingService, log).TrackEvent)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown event type", `{"event_type":"hover","line_item_id":"li_1"}`},
+		{"missing line item", `{"event_type":"impression"}`},
+		{"conversion without user", `{"event_type":"conversion","order_value":10}`},
+		{"negative order value", `{"event_type":"conversion","user_id":"u_1","order_value":-1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/tracking", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if resp.StatusCode != fiber.StatusBadRequest {
+				t.Errorf("TrackEvent(%s) status = %d, want %d", tt.body, resp.StatusCode, fiber.StatusBadRequest)
+			}
+		})
+	}
+	if page, _ := trackingService.Query(service.TrackingEventQuery{}); len(page.Events) != 0 {
+		t.Errorf("stored %d invalid events, want none", len(page.Events))
+	}
+}
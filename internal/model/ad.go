@@ -8,4 +8,7 @@ type Ad struct {
 	Bid          float64 `json:"bid"`
 	Placement    string  `json:"placement"`
 	ServeURL     string  `json:"serve_url"`
+	// Creative is the creative selected by the line item's rotation
+	Creative *Creative `json:"creative,omitempty"`
+	DealID   string    `json:"deal_id,omitempty"`
 }
@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+// AdRequest is the log record of an ad request, with the context the ads were
+// selected for and the outcome of the selection. Logged requests are the
+// history inventory is forecast from and can be replayed offline.
+type AdRequest struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Placement string    `json:"placement"`
+	Category  string    `json:"category,omitempty"`
+	Keyword   string    `json:"keyword,omitempty"`
+	Domain    string    `json:"domain,omitempty"`
+	Geo       Geo       `json:"geo"`
+	Device    Device    `json:"device"`
+	Segments  []string  `json:"segments,omitempty"`
+	Limit     int       `json:"limit"`
+
+	// Constraints of external auctions
+	BidFloor                    float64          `json:"bid_floor,omitempty"`
+	Formats                     []CreativeFormat `json:"formats,omitempty"`
+	Sizes                       []string         `json:"sizes,omitempty"`
+	BlockedAdvertiserDomains    []string         `json:"blocked_advertiser_domains,omitempty"`
+	BlockedAdvertiserCategories []string         `json:"blocked_advertiser_categories,omitempty"`
+	DealIDs                     []string         `json:"deal_ids,omitempty"`
+	PrivateAuction              bool             `json:"private_auction,omitempty"`
+	ExcludeHouse                bool             `json:"exclude_house,omitempty"`
+
+	// Candidates is the number of line items matching the request, Excluded
+	// the number of line items excluded per reason
+	Candidates int            `json:"candidates"`
+	Excluded   map[string]int `json:"excluded,omitempty"`
+	// Scores are the ranked candidates in ranking order
+	Scores  []AdRequestScore  `json:"scores,omitempty"`
+	Winners []AdRequestWinner `json:"winners,omitempty"`
+
+	// SampleRate is the share of ad requests logged when the request was, so
+	// each logged request stands for 1/SampleRate requests
+	SampleRate float64 `json:"sample_rate"`
+}
+
+// Weight returns the number of ad requests the logged request stands for
+func (r *AdRequest) Weight() float64 {
+	if r.SampleRate <= 0 || r.SampleRate >= 1 {
+		return 1
+	}
+	return 1 / r.SampleRate
+}
+
+// AdRequestScore is the ranking of a candidate line item of an ad request
+type AdRequestScore struct {
+	LineItemID string           `json:"line_item_id"`
+	Priority   LineItemPriority `json:"priority"`
+	Tier       int              `json:"tier"`
+	Score      float64          `json:"score"`
+}
+
+// AdRequestWinner is an ad returned for an ad request. Bid is the bid per
+// impression the ad was selected at, which is zero for house ads. External
+// auctions clear at most at the bid, and their clearing prices are only known
+// from the win notices of the bids.
+type AdRequestWinner struct {
+	LineItemID   string  `json:"line_item_id"`
+	AdvertiserID string  `json:"advertiser_id"`
+	CreativeID   string  `json:"creative_id,omitempty"`
+	DealID       string  `json:"deal_id,omitempty"`
+	Bid          float64 `json:"bid"`
+}
+
+// InventoryCount returns the inventory count of the hour of the logged request
+func (r *AdRequest) InventoryCount() *InventoryCount {
+	return &InventoryCount{
+		Hour:      r.Timestamp.UTC().Truncate(time.Hour),
+		Placement: r.Placement,
+		Category:  r.Category,
+		Keyword:   r.Keyword,
+		Domain:    r.Domain,
+		Geo:       r.Geo,
+		Device:    r.Device,
+		Segments:  r.Segments,
+		Requests:  r.Weight(),
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// AdvertiserStatus represents the status of an advertiser account
+type AdvertiserStatus string
+
+const (
+	AdvertiserStatusActive    AdvertiserStatus = "active"
+	AdvertiserStatusSuspended AdvertiserStatus = "suspended"
+)
+
+// Advertiser represents an advertiser account owning line items
+type Advertiser struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Status          AdvertiserStatus `json:"status"`
+	DefaultCurrency string           `json:"default_currency"`
+	// BudgetCap is the account-level spend limit across all line items, zero means unlimited
+	BudgetCap float64 `json:"budget_cap"`
+	Spent     float64 `json:"spent"`
+	// Reserved is held for pending auction bids and counts against the budget cap
+	Reserved float64 `json:"reserved"`
+	Domain   string  `json:"domain"`
+	// Category is the competitive category of the advertiser, ex: automotive
+	Category  string    `json:"category,omitempty"`
+	Blocklist Blocklist `json:"blocklist"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AdvertiserCreate represents the data needed to create a new advertiser
+type AdvertiserCreate struct {
+	Name            string  `json:"name" validate:"required"`
+	DefaultCurrency string  `json:"default_currency" validate:"required,len=3,uppercase"`
+	BudgetCap       float64 `json:"budget_cap" validate:"gte=0"`
+	Domain          string  `json:"domain" validate:"required,fqdn"`
+	Category        string  `json:"category" validate:"omitempty,lowercase"`
+}
+
+// AdvertiserUpdate represents a partial update of an advertiser
+type AdvertiserUpdate struct {
+	Name      *string           `json:"name,omitempty" validate:"omitempty,min=1"`
+	Status    *AdvertiserStatus `json:"status,omitempty" validate:"omitempty,oneof=active suspended"`
+	BudgetCap *float64          `json:"budget_cap,omitempty" validate:"omitempty,gte=0"`
+	Domain    *string           `json:"domain,omitempty" validate:"omitempty,fqdn"`
+	Category  *string           `json:"category,omitempty" validate:"omitempty,lowercase"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Audience is a retargeting audience of an advertiser, materialized from tracking
+// events. Its ID is used as segment ID, so line items target it with include_segments
+// and exclude_segments.
+type Audience struct {
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	AdvertiserID string       `json:"advertiser_id"`
+	Rule         AudienceRule `json:"rule"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// AudienceRule defines which users belong to an audience
+type AudienceRule struct {
+	// EventType is the event on a line item of the advertiser which adds the user
+	EventType TrackingEventType `json:"event_type" validate:"required,oneof=impression click"`
+	// LineItemIDs restricts the events to the given line items, empty means every line item of the advertiser
+	LineItemIDs []string `json:"line_item_ids,omitempty" validate:"dive,required"`
+	// LookbackHours is how long users stay in the audience after their last event
+	LookbackHours int `json:"lookback_hours" validate:"required,gt=0,lte=2160"`
+	// ExcludeConverters removes users with a conversion attributed to the advertiser,
+	// or not attributed to any line item, within the lookback
+	ExcludeConverters bool `json:"exclude_converters,omitempty"`
+}
+
+// Lookback returns the lookback window of the rule
+func (r *AudienceRule) Lookback() time.Duration {
+	return time.Duration(r.LookbackHours) * time.Hour
+}
+
+// AudienceCreate represents the data needed to create a new audience
+type AudienceCreate struct {
+	Name         string       `json:"name" validate:"required"`
+	AdvertiserID string       `json:"advertiser_id" validate:"required"`
+	Rule         AudienceRule `json:"rule"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// BidStatus represents the state of a bid placed into an external auction
+type BidStatus string
+
+const (
+	BidStatusPending BidStatus = "pending"
+	BidStatusWon     BidStatus = "won"
+	BidStatusBilled  BidStatus = "billed"
+	BidStatusLost    BidStatus = "lost"
+)
+
+// Bid is a bid placed into an external auction. Prices are CPM, as in OpenRTB,
+// while line item bids and budgets are per impression. The bid price is
+// reserved from the budgets while the bid is pending.
+type Bid struct {
+	ID           string    `json:"id"`
+	RequestID    string    `json:"request_id"`
+	ImpID        string    `json:"imp_id"`
+	LineItemID   string    `json:"line_item_id"`
+	AdvertiserID string    `json:"advertiser_id"`
+	CampaignID   string    `json:"campaign_id,omitempty"`
+	CreativeID   string    `json:"creative_id,omitempty"`
+	DealID       string    `json:"deal_id,omitempty"`
+	Placement    string    `json:"placement"`
+	Price        float64   `json:"price"`
+	Status       BidStatus `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// ClearingPrice is the auction price reported by the win or billing notice
+	ClearingPrice float64    `json:"clearing_price,omitempty"`
+	WonAt         *time.Time `json:"won_at,omitempty"`
+	BilledAt      *time.Time `json:"billed_at,omitempty"`
+
+	// LossReason is the OpenRTB loss reason code reported by the loss notice
+	LossReason int        `json:"loss_reason,omitempty"`
+	LostAt     *time.Time `json:"lost_at,omitempty"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"slices"
+	"strings"
+)
+
+// BlockReason is the reason an ad request context is blocked by a blocklist
+type BlockReason string
+
+const (
+	BlockReasonPlacement BlockReason = "blocked_placement"
+	BlockReasonCategory  BlockReason = "blocked_category"
+	BlockReasonKeyword   BlockReason = "blocked_keyword"
+	BlockReasonDomain    BlockReason = "blocked_domain"
+)
+
+// Blocklist contains the ad request contexts an advertiser or line item must not serve on
+type Blocklist struct {
+	Placements []string `json:"placements,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Keywords   []string `json:"keywords,omitempty"`
+	// Domains are page domains, blocking their subdomains as well
+	Domains []string `json:"domains,omitempty" validate:"dive,fqdn"`
+}
+
+// Blocks returns the reason the ad request context is blocked, or an empty reason.
+// Categories and keywords are compared case-insensitively.
+func (b *Blocklist) Blocks(placement, category, keyword, domain string) BlockReason {
+	if b == nil {
+		return ""
+	}
+	if placement != "" && slices.Contains(b.Placements, placement) {
+		return BlockReasonPlacement
+	}
+	if category != "" && containsFold(b.Categories, category) {
+		return BlockReasonCategory
+	}
+	if keyword != "" && containsFold(b.Keywords, keyword) {
+		return BlockReasonKeyword
+	}
+	if domain != "" {
+		domain = strings.ToLower(domain)
+		for _, blocked := range b.Domains {
+			blocked = strings.ToLower(blocked)
+			if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+				return BlockReasonDomain
+			}
+		}
+	}
+	return ""
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
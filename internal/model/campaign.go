@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// CampaignStatus represents the status of a campaign
+type CampaignStatus string
+
+const (
+	CampaignStatusActive    CampaignStatus = "active"
+	CampaignStatusPaused    CampaignStatus = "paused"
+	CampaignStatusCompleted CampaignStatus = "completed"
+)
+
+// Campaign groups line items of an advertiser under a shared budget and flight.
+// Reserved is held for pending auction bids and counts against the budget.
+type Campaign struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	AdvertiserID string         `json:"advertiser_id"`
+	Budget       float64        `json:"budget"`
+	Spent        float64        `json:"spent"`
+	Reserved     float64        `json:"reserved"`
+	StartDate    time.Time      `json:"start_date"`
+	EndDate      time.Time      `json:"end_date"`
+	Status       CampaignStatus `json:"status"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// IsServing reports whether line items of the campaign may serve at t
+func (c *Campaign) IsServing(t time.Time) bool {
+	return c.Status == CampaignStatusActive && !t.Before(c.StartDate) && t.Before(c.EndDate)
+}
+
+// CampaignCreate represents the data needed to create a new campaign
+type CampaignCreate struct {
+	Name         string    `json:"name" validate:"required"`
+	AdvertiserID string    `json:"advertiser_id" validate:"required"`
+	Budget       float64   `json:"budget" validate:"required,gt=0"`
+	StartDate    time.Time `json:"start_date" validate:"required"`
+	EndDate      time.Time `json:"end_date" validate:"required,gtfield=StartDate"`
+}
+
+// CampaignUpdate represents a partial update of a campaign
+type CampaignUpdate struct {
+	Name      *string         `json:"name,omitempty" validate:"omitempty,min=1"`
+	Budget    *float64        `json:"budget,omitempty" validate:"omitempty,gt=0"`
+	StartDate *time.Time      `json:"start_date,omitempty"`
+	EndDate   *time.Time      `json:"end_date,omitempty"`
+	Status    *CampaignStatus `json:"status,omitempty" validate:"omitempty,oneof=active paused completed"`
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// CreativeFormat represents the format of a creative
+type CreativeFormat string
+
+const (
+	CreativeFormatImage  CreativeFormat = "image"
+	CreativeFormatHTML   CreativeFormat = "html"
+	CreativeFormatNative CreativeFormat = "native"
+	CreativeFormatVideo  CreativeFormat = "video"
+)
+
+// CreativeStatus represents the approval status of a creative
+type CreativeStatus string
+
+const (
+	CreativeStatusPendingReview CreativeStatus = "pending_review"
+	CreativeStatusApproved      CreativeStatus = "approved"
+	CreativeStatusRejected      CreativeStatus = "rejected"
+)
+
+// NativeAsset contains the fields of a native creative
+type NativeAsset struct {
+	Title        string `json:"title" validate:"required"`
+	Body         string `json:"body,omitempty"`
+	ImageURL     string `json:"image_url,omitempty" validate:"omitempty,url"`
+	IconURL      string `json:"icon_url,omitempty" validate:"omitempty,url"`
+	CallToAction string `json:"call_to_action,omitempty"`
+	Sponsor      string `json:"sponsor,omitempty"`
+}
+
+// VideoAsset contains the media file of a video creative
+type VideoAsset struct {
+	MediaURL string `json:"media_url" validate:"required,url"`
+	MimeType string `json:"mime_type" validate:"required"`
+	// Duration is the length of the video in seconds
+	Duration int `json:"duration" validate:"gt=0"`
+	// Bitrate is the average bitrate in kbps, if known
+	Bitrate int `json:"bitrate,omitempty" validate:"gte=0"`
+}
+
+// Creative represents the content of an advertisement
+type Creative struct {
+	ID           string         `json:"id"`
+	AdvertiserID string         `json:"advertiser_id"`
+	Name         string         `json:"name"`
+	Format       CreativeFormat `json:"format"`
+	Width        int            `json:"width,omitempty"`
+	Height       int            `json:"height,omitempty"`
+	ImageURL     string         `json:"image_url,omitempty"`
+	HTML         string         `json:"html,omitempty"`
+	Native       *NativeAsset   `json:"native,omitempty"`
+	Video        *VideoAsset    `json:"video,omitempty"`
+	LandingURL   string         `json:"landing_url"`
+	Status       CreativeStatus `json:"status"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+
+	// RejectionReasons are set by automatic checks or the reviewer on rejection
+	RejectionReasons []string   `json:"rejection_reasons,omitempty"`
+	ReviewedAt       *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// Size returns the "<width>x<height>" size of the creative
+func (c *Creative) Size() string {
+	return strconv.Itoa(c.Width) + "x" + strconv.Itoa(c.Height)
+}
+
+// CreativeCreate represents the data needed to create a new creative
+type CreativeCreate struct {
+	AdvertiserID string         `json:"advertiser_id" validate:"required"`
+	Name         string         `json:"name" validate:"required"`
+	Format       CreativeFormat `json:"format" validate:"required,oneof=image html native video"`
+	Width        int            `json:"width,omitempty" validate:"required_unless=Format native,gte=0"`
+	Height       int            `json:"height,omitempty" validate:"required_unless=Format native,gte=0"`
+	ImageURL     string         `json:"image_url,omitempty" validate:"required_if=Format image,omitempty,url"`
+	HTML         string         `json:"html,omitempty" validate:"required_if=Format html"`
+	Native       *NativeAsset   `json:"native,omitempty" validate:"required_if=Format native"`
+	Video        *VideoAsset    `json:"video,omitempty" validate:"required_if=Format video"`
+	LandingURL   string         `json:"landing_url" validate:"required"`
+}
+
+// CreativeRejection represents a reviewer's rejection of a creative
+type CreativeRejection struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// CreativeStats contains the delivery counts of a creative used by optimized rotation
+type CreativeStats struct {
+	Impressions int64 `json:"impressions"`
+	Clicks      int64 `json:"clicks"`
+	VideoCounts
+}
+
+// CreativeRotation represents how a line item rotates among its creatives
+type CreativeRotation string
+
+const (
+	// CreativeRotationWeighted picks creatives randomly, proportional to their weights
+	CreativeRotationWeighted CreativeRotation = "weighted"
+	// CreativeRotationOptimized mostly picks the creative with the best observed CTR
+	CreativeRotationOptimized CreativeRotation = "optimized"
+)
+
+// LineItemCreative attaches a creative to a line item
+type LineItemCreative struct {
+	CreativeID string `json:"creative_id" validate:"required"`
+	Weight     int    `json:"weight" validate:"gte=0"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// DealPriceType represents how the price of a deal is applied
+type DealPriceType string
+
+const (
+	// DealPriceTypeFixed deals are bought at the deal price
+	DealPriceTypeFixed DealPriceType = "fixed"
+	// DealPriceTypeFloor deals are bought at a bid of at least the deal price
+	DealPriceTypeFloor DealPriceType = "floor"
+)
+
+// Deal is a private marketplace deal. Line items attached to a deal only serve
+// on ad requests carrying the deal ID.
+type Deal struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	PriceType DealPriceType `json:"price_type"`
+	// Price is per impression, like line item bids
+	Price float64 `json:"price"`
+	// AdvertiserIDs and Placements restrict the line items of the deal, empty means any
+	AdvertiserIDs []string  `json:"advertiser_ids,omitempty"`
+	Placements    []string  `json:"placements,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Allows reports whether a line item of the advertiser on the placement can join the deal
+func (d *Deal) Allows(advertiserID, placement string) bool {
+	return (len(d.AdvertiserIDs) == 0 || slices.Contains(d.AdvertiserIDs, advertiserID)) &&
+		(len(d.Placements) == 0 || slices.Contains(d.Placements, placement))
+}
+
+// DealCreate represents the data needed to create a new deal
+type DealCreate struct {
+	// ID is the deal ID agreed with the publisher or exchange, generated when empty
+	ID            string        `json:"id,omitempty" validate:"omitempty,max=64"`
+	Name          string        `json:"name" validate:"required"`
+	PriceType     DealPriceType `json:"price_type" validate:"required,oneof=fixed floor"`
+	Price         float64       `json:"price" validate:"gt=0"`
+	AdvertiserIDs []string      `json:"advertiser_ids,omitempty" validate:"dive,required"`
+	Placements    []string      `json:"placements,omitempty" validate:"dive,required"`
+}
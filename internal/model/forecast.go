@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// ForecastRequest describes proposed line item targeting to forecast inventory for
+type ForecastRequest struct {
+	Placement string    `json:"placement" validate:"required"`
+	Targeting Targeting `json:"targeting"`
+	StartDate time.Time `json:"start_date" validate:"required"`
+	EndDate   time.Time `json:"end_date" validate:"required,gtfield=StartDate"`
+}
+
+// Forecast estimates the impressions of the proposed targeting over the flight.
+// Available impressions match the targeting, contended impressions are those
+// of them which existing line items of the placement also target.
+type Forecast struct {
+	Placement string        `json:"placement"`
+	StartDate time.Time     `json:"start_date"`
+	EndDate   time.Time     `json:"end_date"`
+	Available int64         `json:"available"`
+	Contended int64         `json:"contended"`
+	Days      []ForecastDay `json:"days"`
+	// HistoryDays is the number of days of ad request history the forecast is based on
+	HistoryDays int `json:"history_days"`
+}
+
+// ForecastDay is the forecast of one UTC day of the flight
+type ForecastDay struct {
+	Date      string `json:"date"`
+	Available int64  `json:"available"`
+	Contended int64  `json:"contended"`
+}
+
+// InventoryCount is the number of ad requests of a placement in an hour with
+// the same targeting attributes, which inventory is forecast from. Requests is
+// weighted by the sampling of the logged requests.
+type InventoryCount struct {
+	Hour      time.Time `json:"hour"`
+	Placement string    `json:"placement"`
+	Category  string    `json:"category,omitempty"`
+	Keyword   string    `json:"keyword,omitempty"`
+	Domain    string    `json:"domain,omitempty"`
+	Geo       Geo       `json:"geo"`
+	Device    Device    `json:"device"`
+	Segments  []string  `json:"segments,omitempty"`
+	Requests  float64   `json:"requests"`
+}
@@ -13,28 +13,115 @@ const (
 	LineItemStatusCompleted LineItemStatus = "completed"
 )
 
-// LineItem represents an advertisement with associated bid information
+// LineItemPriority is the priority tier of a line item. Slots are filled from
+// higher tiers first.
+type LineItemPriority string
+
+const (
+	// LineItemPriorityGuaranteed line items have an impression goal and outrank every other tier
+	LineItemPriorityGuaranteed LineItemPriority = "guaranteed"
+	// LineItemPriorityStandard line items compete on bid and relevancy
+	LineItemPriorityStandard LineItemPriority = "standard"
+	// LineItemPriorityHouse line items are free fallback ads for slots no other tier fills
+	LineItemPriorityHouse LineItemPriority = "house"
+)
+
+// Rank returns the position of the tier in fill order, an empty priority is standard
+func (p LineItemPriority) Rank() int {
+	switch p {
+	case LineItemPriorityGuaranteed:
+		return 0
+	case LineItemPriorityHouse:
+		return 2
+	}
+	return 1
+}
+
+// LineItem represents an advertisement with associated bid information.
+// Budget is the remaining budget, of which Reserved is held for pending
+// auction bids and cannot be spent.
 type LineItem struct {
 	ID           string         `json:"id"`
 	Name         string         `json:"name"`
 	AdvertiserID string         `json:"advertiser_id"`
+	CampaignID   string         `json:"campaign_id,omitempty"`
 	Bid          float64        `json:"bid"`
 	Budget       float64        `json:"budget"`
+	Reserved     float64        `json:"reserved"`
 	Placement    string         `json:"placement"`
 	Categories   []string       `json:"categories,omitempty"`
 	Keywords     []string       `json:"keywords,omitempty"`
 	Status       LineItemStatus `json:"status"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
+
+	// Conversion lookback windows, zero values use the service defaults
+	ClickLookbackHours int `json:"click_lookback_hours,omitempty"`
+	ViewLookbackHours  int `json:"view_lookback_hours,omitempty"`
+
+	Creatives        []LineItemCreative `json:"creatives,omitempty"`
+	CreativeRotation CreativeRotation   `json:"creative_rotation,omitempty"`
+
+	Blocklist Blocklist `json:"blocklist"`
+	Targeting Targeting `json:"targeting"`
+
+	// DealID is the private marketplace deal of the line item, which then only
+	// serves on ad requests carrying the deal ID
+	DealID string `json:"deal_id,omitempty"`
+
+	Priority LineItemPriority `json:"priority"`
+	// ImpressionGoal is the number of impressions promised by a guaranteed line item
+	ImpressionGoal int64 `json:"impression_goal,omitempty"`
+	// StartDate and EndDate are the flight of the line item, it serves from
+	// StartDate until EndDate. A missing bound leaves the flight open.
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+}
+
+// InFlight reports whether t is within the flight of the line item
+func (li *LineItem) InFlight(t time.Time) bool {
+	return (li.StartDate == nil || !t.Before(*li.StartDate)) && (li.EndDate == nil || t.Before(*li.EndDate))
 }
 
 // LineItemCreate represents the data needed to create a new line item
 type LineItemCreate struct {
-	Name         string   `json:"name" validate:"required"`
-	AdvertiserID string   `json:"advertiser_id" validate:"required"`
-	Bid          float64  `json:"bid" validate:"required"`
-	Budget       float64  `json:"budget" validate:"required"`
-	Placement    string   `json:"placement" validate:"required"`
-	Categories   []string `json:"categories,omitempty"`
-	Keywords     []string `json:"keywords,omitempty"`
+	Name               string           `json:"name" validate:"required"`
+	AdvertiserID       string           `json:"advertiser_id" validate:"required"`
+	CampaignID         string           `json:"campaign_id,omitempty"`
+	Bid                float64          `json:"bid" validate:"required_unless=Priority house"`
+	Budget             float64          `json:"budget" validate:"required_unless=Priority house"`
+	Placement          string           `json:"placement" validate:"required"`
+	Categories         []string         `json:"categories,omitempty"`
+	Keywords           []string         `json:"keywords,omitempty"`
+	ClickLookbackHours int              `json:"click_lookback_hours,omitempty" validate:"gte=0"`
+	ViewLookbackHours  int              `json:"view_lookback_hours,omitempty" validate:"gte=0"`
+	CreativeRotation   CreativeRotation `json:"creative_rotation,omitempty" validate:"omitempty,oneof=weighted optimized"`
+	Targeting          Targeting        `json:"targeting"`
+	DealID             string           `json:"deal_id,omitempty" validate:"excluded_if=Priority house"`
+	Priority           LineItemPriority `json:"priority,omitempty" validate:"omitempty,oneof=guaranteed standard house"`
+	ImpressionGoal     int64            `json:"impression_goal,omitempty" validate:"required_if=Priority guaranteed,gte=0"`
+	StartDate          *time.Time       `json:"start_date,omitempty" validate:"required_if=Priority guaranteed"`
+	EndDate            *time.Time       `json:"end_date,omitempty" validate:"required_if=Priority guaranteed"`
+}
+
+// LineItemDelivery is the delivery of a line item towards its impression goal
+type LineItemDelivery struct {
+	LineItemID     string           `json:"line_item_id"`
+	Priority       LineItemPriority `json:"priority"`
+	ImpressionGoal int64            `json:"impression_goal"`
+	StartDate      *time.Time       `json:"start_date,omitempty"`
+	EndDate        *time.Time       `json:"end_date,omitempty"`
+	Impressions    int64            `json:"impressions"`
+	Remaining      int64            `json:"remaining"`
+	// Progress is the delivered share of the goal, between 0 and 1
+	Progress float64 `json:"progress"`
+
+	// Pacing of line items with a goal and a flight. Expected is the number of
+	// impressions due by now on an even schedule, Pace the ratio of delivered
+	// to expected impressions, and Forecast the impressions projected at the end
+	// of the flight at the delivery rate so far.
+	Expected        int64   `json:"expected,omitempty"`
+	Pace            float64 `json:"pace,omitempty"`
+	Forecast        int64   `json:"forecast,omitempty"`
+	Underdelivering bool    `json:"underdelivering"`
 }
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// ReportDimension is a dimension which report rows can be grouped by
+type ReportDimension string
+
+const (
+	ReportDimensionLineItem   ReportDimension = "line_item"
+	ReportDimensionAdvertiser ReportDimension = "advertiser"
+	ReportDimensionPlacement  ReportDimension = "placement"
+)
+
+// ReportInterval is the time bucket size of report rows
+type ReportInterval string
+
+const (
+	ReportIntervalNone ReportInterval = ""
+	ReportIntervalHour ReportInterval = "hour"
+	ReportIntervalDay  ReportInterval = "day"
+)
+
+// ReportRow contains the performance metrics of a single group
+type ReportRow struct {
+	Period          *time.Time `json:"period,omitempty"`
+	LineItemID      string     `json:"line_item_id,omitempty"`
+	AdvertiserID    string     `json:"advertiser_id,omitempty"`
+	Placement       string     `json:"placement,omitempty"`
+	Impressions     int64      `json:"impressions"`
+	Clicks          int64      `json:"clicks"`
+	Conversions     int64      `json:"conversions"`
+	CTR             float64    `json:"ctr"`
+	CVR             float64    `json:"cvr"`
+	Spend           float64    `json:"spend"`
+	ECPM            float64    `json:"ecpm"`
+	Revenue         float64    `json:"revenue"`
+	ROAS            float64    `json:"roas"`
+	RemainingBudget float64    `json:"remaining_budget"`
+	VideoCounts
+}
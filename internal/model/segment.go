@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// SegmentMembership is the membership of a user in an audience segment until it expires
+type SegmentMembership struct {
+	UserID    string    `json:"user_id"`
+	SegmentID string    `json:"segment_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SegmentUpload represents a bulk upload of segment memberships
+type SegmentUpload struct {
+	Memberships []SegmentUploadItem `json:"memberships" validate:"required,min=1,max=10000,dive"`
+}
+
+// SegmentUploadItem adds a user to segments. A zero TTL uses the service default.
+type SegmentUploadItem struct {
+	UserID     string   `json:"user_id" validate:"required"`
+	SegmentIDs []string `json:"segment_ids" validate:"required,min=1,dive,required"`
+	TTLSeconds int      `json:"ttl_seconds,omitempty" validate:"gte=0"`
+}
+
+// SegmentUploadResult summarizes a bulk upload of segment memberships
+type SegmentUploadResult struct {
+	Users       int `json:"users"`
+	Memberships int `json:"memberships"`
+}
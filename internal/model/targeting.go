@@ -0,0 +1,85 @@
+package model
+
+import (
+	"slices"
+	"strings"
+)
+
+// DeviceType is the kind of device an ad request comes from
+type DeviceType string
+
+const (
+	DeviceTypeDesktop DeviceType = "desktop"
+	DeviceTypeMobile  DeviceType = "mobile"
+	DeviceTypeTablet  DeviceType = "tablet"
+	DeviceTypeCTV     DeviceType = "ctv"
+)
+
+// Geo is the location of an ad request
+type Geo struct {
+	// Country is the ISO 3166-1 alpha-2 country code
+	Country string `json:"country,omitempty"`
+	Region  string `json:"region,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+// Device describes the device of an ad request
+type Device struct {
+	Type    DeviceType `json:"type,omitempty"`
+	OS      string     `json:"os,omitempty"`
+	Browser string     `json:"browser,omitempty"`
+}
+
+// Targeting restricts the ad requests a line item serves on. Empty dimensions
+// match every request, values are compared case-insensitively.
+type Targeting struct {
+	Countries   []string     `json:"countries,omitempty" validate:"dive,len=2"`
+	Regions     []string     `json:"regions,omitempty"`
+	Cities      []string     `json:"cities,omitempty"`
+	DeviceTypes []DeviceType `json:"device_types,omitempty" validate:"dive,oneof=desktop mobile tablet ctv"`
+	OS          []string     `json:"os,omitempty"`
+	Browsers    []string     `json:"browsers,omitempty"`
+
+	// IncludeSegments requires the user to belong to at least one of the segments,
+	// ExcludeSegments rejects users belonging to any of them
+	IncludeSegments []string `json:"include_segments,omitempty" validate:"dive,required"`
+	ExcludeSegments []string `json:"exclude_segments,omitempty" validate:"dive,required"`
+}
+
+// MatchesGeo reports whether the geo targeting matches the request location.
+// A targeted dimension never matches an unknown location.
+func (t *Targeting) MatchesGeo(geo Geo) bool {
+	return matchesDimension(t.Countries, geo.Country) &&
+		matchesDimension(t.Regions, geo.Region) &&
+		matchesDimension(t.Cities, geo.City)
+}
+
+// MatchesDevice reports whether the device targeting matches the request device.
+// A targeted dimension never matches an unknown device.
+func (t *Targeting) MatchesDevice(device Device) bool {
+	return matchesDimension(t.DeviceTypes, device.Type) &&
+		matchesDimension(t.OS, device.OS) &&
+		matchesDimension(t.Browsers, device.Browser)
+}
+
+// MatchesSegments reports whether the segment targeting matches the segments of
+// the requesting user. Included segments never match an unknown user.
+func (t *Targeting) MatchesSegments(segments []string) bool {
+	if len(t.IncludeSegments) > 0 && !slices.ContainsFunc(t.IncludeSegments, func(s string) bool {
+		return slices.Contains(segments, s)
+	}) {
+		return false
+	}
+	return !slices.ContainsFunc(t.ExcludeSegments, func(s string) bool {
+		return slices.Contains(segments, s)
+	})
+}
+
+func matchesDimension[T ~string](targeted []T, value T) bool {
+	if len(targeted) == 0 {
+		return true
+	}
+	return value != "" && slices.ContainsFunc(targeted, func(v T) bool {
+		return strings.EqualFold(string(v), string(value))
+	})
+}
@@ -9,14 +9,169 @@ const (
 	TrackingEventTypeImpression TrackingEventType = "impression"
 	TrackingEventTypeClick      TrackingEventType = "click"
 	TrackingEventTypeConversion TrackingEventType = "conversion"
+
+	// Video playback events reported by VAST players
+	TrackingEventTypeVideoStart         TrackingEventType = "video_start"
+	TrackingEventTypeVideoFirstQuartile TrackingEventType = "video_first_quartile"
+	TrackingEventTypeVideoMidpoint      TrackingEventType = "video_midpoint"
+	TrackingEventTypeVideoThirdQuartile TrackingEventType = "video_third_quartile"
+	TrackingEventTypeVideoComplete      TrackingEventType = "video_complete"
+)
+
+// IsTrackingEventType reports whether t is a known tracking event type
+func IsTrackingEventType(t TrackingEventType) bool {
+	switch t {
+	case TrackingEventTypeImpression, TrackingEventTypeClick, TrackingEventTypeConversion,
+		TrackingEventTypeVideoStart, TrackingEventTypeVideoFirstQuartile, TrackingEventTypeVideoMidpoint,
+		TrackingEventTypeVideoThirdQuartile, TrackingEventTypeVideoComplete:
+		return true
+	}
+	return false
+}
+
+// AttributionType represents the touchpoint a conversion is attributed to
+type AttributionType string
+
+const (
+	AttributionTypeClick      AttributionType = "click"
+	AttributionTypeImpression AttributionType = "impression"
+	AttributionTypeNone       AttributionType = "none"
+)
+
+// InvalidTrafficReason is the reason code of an event flagged as invalid traffic
+type InvalidTrafficReason string
+
+const (
+	InvalidTrafficReasonBotUserAgent           InvalidTrafficReason = "bot_user_agent"
+	InvalidTrafficReasonIPBurst                InvalidTrafficReason = "ip_burst"
+	InvalidTrafficReasonUserBurst              InvalidTrafficReason = "user_burst"
+	InvalidTrafficReasonClickWithoutImpression InvalidTrafficReason = "click_without_impression"
+	InvalidTrafficReasonFastClick              InvalidTrafficReason = "fast_click"
 )
 
-// TrackingEvent represents a user interaction with an ad
+// TrackingEvent represents a user interaction with an ad.
+// Conversions are attributed by the server, so their LineItemID is set from the
+// attributed touchpoint rather than taken from the client.
 type TrackingEvent struct {
-	EventType  TrackingEventType `json:"event_type" validate:"required,oneof=impression click conversion"`
-	LineItemID string            `json:"line_item_id" validate:"required"`
+	ID         string            `json:"id,omitempty"`
+	EventType  TrackingEventType `json:"event_type" validate:"required,oneof=impression click conversion video_start video_first_quartile video_midpoint video_third_quartile video_complete"`
+	LineItemID string            `json:"line_item_id" validate:"required_unless=EventType conversion"`
+	CreativeID string            `json:"creative_id,omitempty"`
 	Timestamp  time.Time         `json:"timestamp,omitempty"`
 	Placement  string            `json:"placement,omitempty"`
-	UserID     string            `json:"user_id,omitempty"`
+	UserID     string            `json:"user_id,omitempty" validate:"required_if=EventType conversion"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+
+	// Cost is the amount charged for an impression, set by the server: the bid
+	// spent when the ad was served, or the clearing price of a won external auction
+	Cost float64 `json:"cost,omitempty"`
+
+	// InvalidReason is set on events flagged as invalid traffic. Flagged events
+	// are stored but excluded from billing, rollups and reports.
+	InvalidReason InvalidTrafficReason `json:"invalid_reason,omitempty"`
+
+	// Conversion only fields
+	OrderValue        float64         `json:"order_value,omitempty" validate:"gte=0"`
+	Attribution       AttributionType `json:"attribution,omitempty"`
+	AttributedEventID string          `json:"attributed_event_id,omitempty"`
+}
+
+// IsValid reports whether the event is not flagged as invalid traffic
+func (e *TrackingEvent) IsValid() bool {
+	return e.InvalidReason == ""
+}
+
+// TrackingEventPage is a page of tracking events with the cursor of the next page
+type TrackingEventPage struct {
+	Events     []*TrackingEvent `json:"events"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// RollupGranularity is the time bucket size of a rollup counter
+type RollupGranularity string
+
+const (
+	RollupGranularityMinute RollupGranularity = "minute"
+	RollupGranularityHour   RollupGranularity = "hour"
+)
+
+// Truncate returns the start of the bucket containing t
+func (g RollupGranularity) Truncate(t time.Time) time.Time {
+	if g == RollupGranularityHour {
+		return t.UTC().Truncate(time.Hour)
+	}
+	return t.UTC().Truncate(time.Minute)
+}
+
+// VideoCounts contains the counts of video playback events
+type VideoCounts struct {
+	VideoStarts         int64 `json:"video_starts"`
+	VideoFirstQuartiles int64 `json:"video_first_quartiles"`
+	VideoMidpoints      int64 `json:"video_midpoints"`
+	VideoThirdQuartiles int64 `json:"video_third_quartiles"`
+	VideoCompletes      int64 `json:"video_completes"`
+}
+
+// Add increments the counter of the given video event type
+func (c *VideoCounts) Add(eventType TrackingEventType) {
+	switch eventType {
+	case TrackingEventTypeVideoStart:
+		c.VideoStarts++
+	case TrackingEventTypeVideoFirstQuartile:
+		c.VideoFirstQuartiles++
+	case TrackingEventTypeVideoMidpoint:
+		c.VideoMidpoints++
+	case TrackingEventTypeVideoThirdQuartile:
+		c.VideoThirdQuartiles++
+	case TrackingEventTypeVideoComplete:
+		c.VideoCompletes++
+	}
+}
+
+// Merge adds the counts of other
+func (c *VideoCounts) Merge(other VideoCounts) {
+	c.VideoStarts += other.VideoStarts
+	c.VideoFirstQuartiles += other.VideoFirstQuartiles
+	c.VideoMidpoints += other.VideoMidpoints
+	c.VideoThirdQuartiles += other.VideoThirdQuartiles
+	c.VideoCompletes += other.VideoCompletes
+}
+
+// Rollup contains pre-aggregated event counts of a line item and placement within a time bucket
+type Rollup struct {
+	LineItemID  string            `json:"line_item_id"`
+	Placement   string            `json:"placement"`
+	Granularity RollupGranularity `json:"granularity"`
+	Period      time.Time         `json:"period"`
+	Impressions int64             `json:"impressions"`
+	Clicks      int64             `json:"clicks"`
+	Conversions int64             `json:"conversions"`
+	VideoCounts
+}
+
+// Add increments the counter of the given event type
+func (r *Rollup) Add(eventType TrackingEventType) {
+	switch eventType {
+	case TrackingEventTypeImpression:
+		r.Impressions++
+	case TrackingEventTypeClick:
+		r.Clicks++
+	case TrackingEventTypeConversion:
+		r.Conversions++
+	default:
+		r.VideoCounts.Add(eventType)
+	}
+}
+
+// LineItemStats contains near-real-time counts of a line item served from rollups
+type LineItemStats struct {
+	LineItemID  string            `json:"line_item_id"`
+	Granularity RollupGranularity `json:"granularity"`
+	Impressions int64             `json:"impressions"`
+	Clicks      int64             `json:"clicks"`
+	Conversions int64             `json:"conversions"`
+	Series      []*Rollup         `json:"series"`
+	VideoCounts
 }
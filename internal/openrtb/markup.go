@@ -0,0 +1,89 @@
+package openrtb
+
+import (
+	"encoding/json"
+	"html"
+	"strconv"
+
+	"sweng-task/internal/model"
+)
+
+// Markup returns the ad markup of a creative for the adm field of a bid. Native
+// creatives are returned as a Native Ads 1.2 response with title, image and body assets.
+func Markup(creative *model.Creative) string {
+	switch creative.Format {
+	case model.CreativeFormatHTML:
+		return creative.HTML
+	case model.CreativeFormatNative:
+		return nativeMarkup(creative)
+	}
+	return `<a href="` + html.EscapeString(creative.LandingURL) + `" target="_blank"><img src="` + html.EscapeString(creative.ImageURL) +
+		`" width="` + strconv.Itoa(creative.Width) + `" height="` + strconv.Itoa(creative.Height) + `" alt="" style="border:0"></a>`
+}
+
+type nativeResponse struct {
+	Native nativeAd `json:"native"`
+}
+
+type nativeAd struct {
+	Ver    string        `json:"ver"`
+	Assets []nativeAsset `json:"assets"`
+	Link   nativeLink    `json:"link"`
+}
+
+type nativeAsset struct {
+	ID    int          `json:"id"`
+	Title *nativeTitle `json:"title,omitempty"`
+	Img   *nativeImage `json:"img,omitempty"`
+	Data  *nativeData  `json:"data,omitempty"`
+}
+
+type nativeTitle struct {
+	Text string `json:"text"`
+}
+
+type nativeImage struct {
+	URL string `json:"url"`
+}
+
+type nativeData struct {
+	Value string `json:"value"`
+}
+
+type nativeLink struct {
+	URL string `json:"url"`
+}
+
+// Asset IDs of native responses
+const (
+	nativeAssetTitle = iota + 1
+	nativeAssetImage
+	nativeAssetBody
+	nativeAssetIcon
+	nativeAssetSponsor
+	nativeAssetCallToAction
+)
+
+func nativeMarkup(creative *model.Creative) string {
+	ad := nativeAd{Ver: "1.2", Link: nativeLink{URL: creative.LandingURL}}
+	if asset := creative.Native; asset != nil {
+		ad.Assets = append(ad.Assets, nativeAsset{ID: nativeAssetTitle, Title: &nativeTitle{Text: asset.Title}})
+		if asset.ImageURL != "" {
+			ad.Assets = append(ad.Assets, nativeAsset{ID: nativeAssetImage, Img: &nativeImage{URL: asset.ImageURL}})
+		}
+		if asset.Body != "" {
+			ad.Assets = append(ad.Assets, nativeAsset{ID: nativeAssetBody, Data: &nativeData{Value: asset.Body}})
+		}
+		if asset.IconURL != "" {
+			ad.Assets = append(ad.Assets, nativeAsset{ID: nativeAssetIcon, Img: &nativeImage{URL: asset.IconURL}})
+		}
+		if asset.Sponsor != "" {
+			ad.Assets = append(ad.Assets, nativeAsset{ID: nativeAssetSponsor, Data: &nativeData{Value: asset.Sponsor}})
+		}
+		if asset.CallToAction != "" {
+			ad.Assets = append(ad.Assets, nativeAsset{ID: nativeAssetCallToAction, Data: &nativeData{Value: asset.CallToAction}})
+		}
+	}
+	markup, _ := json.Marshal(nativeResponse{Native: ad})
+	return string(markup)
+}
@@ -0,0 +1,264 @@
+// Package openrtb contains the subset of the OpenRTB 2.6 bid request and bid
+// response objects used by the auction endpoint
+package openrtb
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"sweng-task/internal/model"
+)
+
+// Macros replaced by the exchange in notice URLs
+const (
+	// AuctionPriceMacro is the clearing price of the auction
+	AuctionPriceMacro = "${AUCTION_PRICE}"
+	// AuctionLossMacro is the loss reason code of a lost bid
+	AuctionLossMacro = "${AUCTION_LOSS}"
+)
+
+// No-bid reason codes
+const (
+	NoBidUnknownError      = 0
+	NoBidTechnicalError    = 1
+	NoBidInvalidRequest    = 2
+	NoBidUnsupportedDevice = 7
+)
+
+// Markup types of bids
+const (
+	MarkupBanner = 1
+	MarkupVideo  = 2
+	MarkupNative = 4
+)
+
+// BidRequest is the top-level bid request object
+type BidRequest struct {
+	ID     string   `json:"id" validate:"required"`
+	Imp    []Imp    `json:"imp" validate:"required,min=1,dive"`
+	Site   *Site    `json:"site,omitempty"`
+	App    *App     `json:"app,omitempty"`
+	Device *Device  `json:"device,omitempty"`
+	User   *User    `json:"user,omitempty"`
+	Test   int      `json:"test,omitempty"`
+	AT     int      `json:"at,omitempty"`
+	TMax   int      `json:"tmax,omitempty"`
+	Cur    []string `json:"cur,omitempty"`
+	// BCat are blocked advertiser categories, BAdv are blocked advertiser domains
+	BCat []string        `json:"bcat,omitempty"`
+	BAdv []string        `json:"badv,omitempty"`
+	Ext  json.RawMessage `json:"ext,omitempty"`
+}
+
+// Imp describes an ad slot of the bid request
+type Imp struct {
+	ID     string  `json:"id" validate:"required"`
+	Banner *Banner `json:"banner,omitempty"`
+	Native *Native `json:"native,omitempty"`
+	PMP    *PMP    `json:"pmp,omitempty"`
+	// TagID identifies the ad slot, and is used as placement
+	TagID       string          `json:"tagid,omitempty"`
+	BidFloor    float64         `json:"bidfloor,omitempty" validate:"gte=0"`
+	BidFloorCur string          `json:"bidfloorcur,omitempty"`
+	Secure      *int            `json:"secure,omitempty"`
+	Ext         json.RawMessage `json:"ext,omitempty"`
+}
+
+// ImpExt is the impression extension of Prebid Server requests, which carries
+// the parameters of the bidder from the ad unit
+type ImpExt struct {
+	Bidder BidderParams `json:"bidder"`
+}
+
+// BidderParams are the parameters of our bidder in a Prebid ad unit
+type BidderParams struct {
+	Placement string `json:"placement"`
+}
+
+// Banner describes a banner ad slot
+type Banner struct {
+	W      int      `json:"w,omitempty"`
+	H      int      `json:"h,omitempty"`
+	Format []Format `json:"format,omitempty"`
+}
+
+// Format is an allowed banner size
+type Format struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Native describes a native ad slot, the request is an encoded Native Ads request
+type Native struct {
+	Request string `json:"request"`
+	Ver     string `json:"ver,omitempty"`
+}
+
+// PMP describes the private marketplace deals of an impression
+type PMP struct {
+	// PrivateAuction 1 restricts bids to the deals
+	PrivateAuction int    `json:"private_auction,omitempty"`
+	Deals          []Deal `json:"deals,omitempty"`
+}
+
+// Deal is a deal offered on an impression
+type Deal struct {
+	ID          string  `json:"id"`
+	BidFloor    float64 `json:"bidfloor,omitempty"`
+	BidFloorCur string  `json:"bidfloorcur,omitempty"`
+}
+
+// Site describes the website of the ad request
+type Site struct {
+	ID       string   `json:"id,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Domain   string   `json:"domain,omitempty"`
+	Page     string   `json:"page,omitempty"`
+	Cat      []string `json:"cat,omitempty"`
+	Keywords string   `json:"keywords,omitempty"`
+}
+
+// App describes the application of the ad request
+type App struct {
+	ID       string   `json:"id,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Bundle   string   `json:"bundle,omitempty"`
+	Domain   string   `json:"domain,omitempty"`
+	Cat      []string `json:"cat,omitempty"`
+	Keywords string   `json:"keywords,omitempty"`
+}
+
+// Device describes the device of the user
+type Device struct {
+	UA         string `json:"ua,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	IPv6       string `json:"ipv6,omitempty"`
+	Geo        *Geo   `json:"geo,omitempty"`
+	DeviceType int    `json:"devicetype,omitempty"`
+	OS         string `json:"os,omitempty"`
+}
+
+// Geo describes a location
+type Geo struct {
+	// Country is the ISO 3166-1 alpha-3 country code
+	Country string `json:"country,omitempty"`
+	// Region is the ISO 3166-2 region code
+	Region string `json:"region,omitempty"`
+	City   string `json:"city,omitempty"`
+}
+
+// User describes the user of the device
+type User struct {
+	ID string `json:"id,omitempty"`
+	// BuyerUID is the user ID of the buyer, which is our user ID
+	BuyerUID string `json:"buyeruid,omitempty"`
+	Keywords string `json:"keywords,omitempty"`
+	Geo      *Geo   `json:"geo,omitempty"`
+}
+
+// BidResponse is the top-level bid response object
+type BidResponse struct {
+	ID      string    `json:"id"`
+	SeatBid []SeatBid `json:"seatbid,omitempty"`
+	BidID   string    `json:"bidid,omitempty"`
+	Cur     string    `json:"cur,omitempty"`
+	NBR     *int      `json:"nbr,omitempty"`
+}
+
+// SeatBid is a set of bids of one seat
+type SeatBid struct {
+	Bid  []Bid  `json:"bid"`
+	Seat string `json:"seat,omitempty"`
+}
+
+// Bid is an offer to buy an impression
+type Bid struct {
+	ID    string  `json:"id"`
+	ImpID string  `json:"impid"`
+	Price float64 `json:"price"`
+	// NURL is the win notice URL, BURL the billing notice URL and LURL the loss notice URL
+	NURL    string   `json:"nurl,omitempty"`
+	BURL    string   `json:"burl,omitempty"`
+	LURL    string   `json:"lurl,omitempty"`
+	AdM     string   `json:"adm,omitempty"`
+	AdID    string   `json:"adid,omitempty"`
+	ADomain []string `json:"adomain,omitempty"`
+	CID     string   `json:"cid,omitempty"`
+	CrID    string   `json:"crid,omitempty"`
+	Cat     []string `json:"cat,omitempty"`
+	W       int      `json:"w,omitempty"`
+	H       int      `json:"h,omitempty"`
+	DealID  string   `json:"dealid,omitempty"`
+	MType   int      `json:"mtype,omitempty"`
+	Ext     *BidExt  `json:"ext,omitempty"`
+}
+
+// BidExt is the bid extension read by Prebid Server
+type BidExt struct {
+	Prebid BidExtPrebid `json:"prebid"`
+}
+
+// BidExtPrebid contains the media type of a bid: banner, video or native
+type BidExtPrebid struct {
+	Type string `json:"type"`
+}
+
+// BidderParams returns the bidder parameters of a Prebid Server impression,
+// which are empty when the impression has no readable extension
+func (imp *Imp) BidderParams() BidderParams {
+	var ext ImpExt
+	if len(imp.Ext) > 0 {
+		_ = json.Unmarshal(imp.Ext, &ext)
+	}
+	return ext.Bidder
+}
+
+// CountryAlpha2 returns the ISO 3166-1 alpha-2 code of the country, accepting
+// alpha-2 codes as sent by some exchanges. Unknown countries return "".
+func (g *Geo) CountryAlpha2() string {
+	country := strings.ToUpper(g.Country)
+	if len(country) == 2 {
+		return country
+	}
+	return countryAlpha2[country]
+}
+
+// RegionCode returns the subdivision part of the region, ex: "CA" for "US-CA"
+func (g *Geo) RegionCode() string {
+	if _, region, ok := strings.Cut(g.Region, "-"); ok {
+		return region
+	}
+	return g.Region
+}
+
+// Type maps the OpenRTB device type to the targeting device type
+func (d *Device) Type() model.DeviceType {
+	switch d.DeviceType {
+	case 1, 4:
+		return model.DeviceTypeMobile
+	case 2:
+		return model.DeviceTypeDesktop
+	case 3, 7:
+		return model.DeviceTypeCTV
+	case 5:
+		return model.DeviceTypeTablet
+	}
+	return ""
+}
+
+// Sizes returns the allowed "<width>x<height>" sizes of the banner
+func (b *Banner) Sizes() []string {
+	var sizes []string
+	if b.W > 0 && b.H > 0 {
+		sizes = append(sizes, formatSize(b.W, b.H))
+	}
+	for _, f := range b.Format {
+		sizes = append(sizes, formatSize(f.W, f.H))
+	}
+	return sizes
+}
+
+func formatSize(w, h int) string {
+	return strconv.Itoa(w) + "x" + strconv.Itoa(h)
+}
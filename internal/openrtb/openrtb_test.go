@@ -0,0 +1,85 @@
+package openrtb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"sweng-task/internal/model"
+)
+
+func TestGeo(t *testing.T) {
+	tests := []struct {
+		geo         Geo
+		wantCountry string
+		wantRegion  string
+	}{
+		{Geo{Country: "USA", Region: "US-CA"}, "US", "CA"},
+		{Geo{Country: "deu", Region: "BE"}, "DE", "BE"},
+		{Geo{Country: "gb"}, "GB", ""},
+		{Geo{Country: "XXX"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.geo.Country, func(t *testing.T) {
+			if got := tt.geo.CountryAlpha2(); got != tt.wantCountry {
+				t.Errorf("CountryAlpha2() = %q, want %q", got, tt.wantCountry)
+			}
+			if got := tt.geo.RegionCode(); got != tt.wantRegion {
+				t.Errorf("RegionCode() = %q, want %q", got, tt.wantRegion)
+			}
+		})
+	}
+}
+
+func TestDevice_Type(t *testing.T) {
+	want := map[int]model.DeviceType{
+		0: "",
+		1: model.DeviceTypeMobile,
+		2: model.DeviceTypeDesktop,
+		3: model.DeviceTypeCTV,
+		4: model.DeviceTypeMobile,
+		5: model.DeviceTypeTablet,
+		6: "",
+		7: model.DeviceTypeCTV,
+	}
+	for deviceType, w := range want {
+		if got := (&Device{DeviceType: deviceType}).Type(); got != w {
+			t.Errorf("Type() of %d = %q, want %q", deviceType, got, w)
+		}
+	}
+}
+
+func TestMarkup(t *testing.T) {
+	image := Markup(&model.Creative{Format: model.CreativeFormatImage, ImageURL: "https://cdn.example/a.png?x=1&y=2", LandingURL: "https://brand.example", Width: 300, Height: 250})
+	want := `<a href="https://brand.example" target="_blank"><img src="https://cdn.example/a.png?x=1&amp;y=2" width="300" height="250" alt="" style="border:0"></a>`
+	if image != want {
+		t.Errorf("Markup() image = %s, want %s", image, want)
+	}
+
+	native := Markup(&model.Creative{Format: model.CreativeFormatNative, LandingURL: "https://brand.example",
+		Native: &model.NativeAsset{Title: "Hello", Body: "World"}})
+	var got nativeResponse
+	if err := json.Unmarshal([]byte(native), &got); err != nil {
+		t.Fatalf("Markup() native is not JSON: %v", err)
+	}
+	if len(got.Native.Assets) != 2 || got.Native.Assets[0].Title.Text != "Hello" || got.Native.Assets[1].Data.Value != "World" || got.Native.Link.URL != "https://brand.example" {
+		t.Errorf("Markup() native = %s", native)
+	}
+}
+
+func TestImp_BidderParams(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{`{"bidder":{"placement":"sidebar"},"prebid":{"storedrequest":{"id":"1"}}}`, "sidebar"},
+		{`{"bidder":{}}`, ""},
+		{`{"bidder":"invalid"}`, ""},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		imp := Imp{Ext: json.RawMessage(tt.ext)}
+		if got := imp.BidderParams().Placement; got != tt.want {
+			t.Errorf("BidderParams() of %s placement = %q, want %q", tt.ext, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+)
+
+type GetAdvertisersFilter struct {
+	Status model.AdvertiserStatus
+}
+
+type AdvertiserRepository interface {
+	CreateAdvertiser(advertiser *model.Advertiser) error
+	GetAdvertiserById(id string) (*model.Advertiser, error)
+	GetAdvertisers(filter GetAdvertisersFilter) ([]*model.Advertiser, error)
+	UpdateAdvertiser(advertiser *model.Advertiser) error
+	// AddSpend atomically adds spent to the advertiser spend and reserved to its
+	// reservations. Increases fail with ErrAdvertiserBudgetExceeded when
+	// the spend and reservations would exceed the budget cap.
+	AddSpend(id string, spent, reserved float64) (*model.Advertiser, error)
+}
+
+var _ AdvertiserRepository = (*AdvertiserRepositoryImp)(nil)
+
+type AdvertiserRepositoryImp struct {
+	advertisers map[string]*model.Advertiser
+	mu          sync.RWMutex
+	log         *zap.SugaredLogger
+}
+
+func NewAdvertiserRepository(log *zap.SugaredLogger) AdvertiserRepository {
+	return &AdvertiserRepositoryImp{
+		advertisers: make(map[string]*model.Advertiser),
+		log:         log,
+	}
+}
+
+func (s *AdvertiserRepositoryImp) CreateAdvertiser(advertiser *model.Advertiser) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advertisers[advertiser.ID] = advertiser
+	return nil
+}
+
+func (s *AdvertiserRepositoryImp) GetAdvertiserById(id string) (*model.Advertiser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.advertisers[id], nil
+}
+
+func (s *AdvertiserRepositoryImp) GetAdvertisers(filter GetAdvertisersFilter) ([]*model.Advertiser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.Advertiser, 0)
+	for _, advertiser := range s.advertisers {
+		if filter.Status != "" && advertiser.Status != filter.Status {
+			continue
+		}
+		result = append(result, advertiser)
+	}
+	return result, nil
+}
+
+func (s *AdvertiserRepositoryImp) UpdateAdvertiser(advertiser *model.Advertiser) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.advertisers[advertiser.ID]
+	if !ok {
+		return domain_errors.ErrAdvertiserNotFound
+	}
+	// spend and reservations are only changed through AddSpend
+	advertiser.Spent = existing.Spent
+	advertiser.Reserved = existing.Reserved
+	s.advertisers[advertiser.ID] = advertiser
+	return nil
+}
+
+func (s *AdvertiserRepositoryImp) AddSpend(id string, spent, reserved float64) (*model.Advertiser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	advertiser, ok := s.advertisers[id]
+	if !ok {
+		return nil, domain_errors.ErrAdvertiserNotFound
+	}
+	if spent+reserved > 0 && advertiser.BudgetCap > 0 && advertiser.Spent+advertiser.Reserved+spent+reserved > advertiser.BudgetCap {
+		return nil, domain_errors.ErrAdvertiserBudgetExceeded
+	}
+	// copy on write, so readers holding the previous value are not affected
+	updated := *advertiser
+	updated.Spent += spent
+	updated.Reserved += reserved
+	s.advertisers[id] = &updated
+	return &updated, nil
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+)
+
+type GetAudiencesFilter struct {
+	AdvertiserID string
+}
+
+type AudienceRepository interface {
+	CreateAudience(audience *model.Audience) error
+	GetAudienceById(id string) (*model.Audience, error)
+	GetAudiences(filter GetAudiencesFilter) ([]*model.Audience, error)
+}
+
+var _ AudienceRepository = (*AudienceRepositoryImp)(nil)
+
+type AudienceRepositoryImp struct {
+	audiences map[string]*model.Audience
+	mu        sync.RWMutex
+	log       *zap.SugaredLogger
+}
+
+func NewAudienceRepository(log *zap.SugaredLogger) AudienceRepository {
+	return &AudienceRepositoryImp{
+		audiences: make(map[string]*model.Audience),
+		log:       log,
+	}
+}
+
+func (s *AudienceRepositoryImp) CreateAudience(audience *model.Audience) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audiences[audience.ID] = audience
+	return nil
+}
+
+func (s *AudienceRepositoryImp) GetAudienceById(id string) (*model.Audience, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.audiences[id], nil
+}
+
+func (s *AudienceRepositoryImp) GetAudiences(filter GetAudiencesFilter) ([]*model.Audience, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.Audience, 0)
+	for _, audience := range s.audiences {
+		if filter.AdvertiserID != "" && audience.AdvertiserID != filter.AdvertiserID {
+			continue
+		}
+		result = append(result, audience)
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+)
+
+type BidRepository interface {
+	CreateBid(bid *model.Bid) error
+	GetBidById(id string) (*model.Bid, error)
+	// UpdateStatus atomically applies update to a copy of the bid when its status
+	// is one of from, and fails with ErrBidStatusConflict otherwise
+	UpdateStatus(id string, from []model.BidStatus, update func(bid *model.Bid)) (*model.Bid, error)
+	// DeleteBefore removes bids created before t whose status is one of
+	// statuses, and returns them
+	DeleteBefore(t time.Time, statuses []model.BidStatus) ([]*model.Bid, error)
+}
+
+var _ BidRepository = (*BidRepositoryImp)(nil)
+
+type BidRepositoryImp struct {
+	bids map[string]*model.Bid
+	mu   sync.RWMutex
+	log  *zap.SugaredLogger
+}
+
+func NewBidRepository(log *zap.SugaredLogger) BidRepository {
+	return &BidRepositoryImp{
+		bids: make(map[string]*model.Bid),
+		log:  log,
+	}
+}
+
+func (s *BidRepositoryImp) CreateBid(bid *model.Bid) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bids[bid.ID] = bid
+	return nil
+}
+
+func (s *BidRepositoryImp) GetBidById(id string) (*model.Bid, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.bids[id], nil
+}
+
+func (s *BidRepositoryImp) UpdateStatus(id string, from []model.BidStatus, update func(bid *model.Bid)) (*model.Bid, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bid, ok := s.bids[id]
+	if !ok {
+		return nil, domain_errors.ErrBidNotFound
+	}
+	if !slices.Contains(from, bid.Status) {
+		return bid, domain_errors.ErrBidStatusConflict
+	}
+	// copy on write, so readers holding the previous value are not affected
+	updated := *bid
+	update(&updated)
+	s.bids[id] = &updated
+	return &updated, nil
+}
+
+func (s *BidRepositoryImp) DeleteBefore(t time.Time, statuses []model.BidStatus) ([]*model.Bid, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted []*model.Bid
+	for id, bid := range s.bids {
+		if bid.CreatedAt.Before(t) && slices.Contains(statuses, bid.Status) {
+			delete(s.bids, id)
+			deleted = append(deleted, bid)
+		}
+	}
+	return deleted, nil
+}
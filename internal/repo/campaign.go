@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+)
+
+type GetCampaignsFilter struct {
+	AdvertiserID string
+	Status       model.CampaignStatus
+}
+
+type CampaignRepository interface {
+	CreateCampaign(campaign *model.Campaign) error
+	GetCampaignById(id string) (*model.Campaign, error)
+	GetCampaigns(filter GetCampaignsFilter) ([]*model.Campaign, error)
+	UpdateCampaign(campaign *model.Campaign) error
+	// AddSpend atomically adds spent to the campaign spend and reserved to its
+	// reservations. Increases fail with ErrCampaignBudgetExceeded when
+	// the spend and reservations would exceed the budget.
+	AddSpend(id string, spent, reserved float64) (*model.Campaign, error)
+}
+
+var _ CampaignRepository = (*CampaignRepositoryImp)(nil)
+
+type CampaignRepositoryImp struct {
+	campaigns map[string]*model.Campaign
+	mu        sync.RWMutex
+	log       *zap.SugaredLogger
+}
+
+func NewCampaignRepository(log *zap.SugaredLogger) CampaignRepository {
+	return &CampaignRepositoryImp{
+		campaigns: make(map[string]*model.Campaign),
+		log:       log,
+	}
+}
+
+func (s *CampaignRepositoryImp) CreateCampaign(campaign *model.Campaign) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.campaigns[campaign.ID] = campaign
+	return nil
+}
+
+func (s *CampaignRepositoryImp) GetCampaignById(id string) (*model.Campaign, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.campaigns[id], nil
+}
+
+func (s *CampaignRepositoryImp) GetCampaigns(filter GetCampaignsFilter) ([]*model.Campaign, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.Campaign, 0)
+	for _, campaign := range s.campaigns {
+		if filter.AdvertiserID != "" && campaign.AdvertiserID != filter.AdvertiserID {
+			continue
+		}
+		if filter.Status != "" && campaign.Status != filter.Status {
+			continue
+		}
+		result = append(result, campaign)
+	}
+	return result, nil
+}
+
+func (s *CampaignRepositoryImp) UpdateCampaign(campaign *model.Campaign) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.campaigns[campaign.ID]
+	if !ok {
+		return domain_errors.ErrCampaignNotFound
+	}
+	// spend and reservations are only changed through AddSpend
+	campaign.Spent = existing.Spent
+	campaign.Reserved = existing.Reserved
+	s.campaigns[campaign.ID] = campaign
+	return nil
+}
+
+func (s *CampaignRepositoryImp) AddSpend(id string, spent, reserved float64) (*model.Campaign, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaign, ok := s.campaigns[id]
+	if !ok {
+		return nil, domain_errors.ErrCampaignNotFound
+	}
+	if spent+reserved > 0 && campaign.Spent+campaign.Reserved+spent+reserved > campaign.Budget {
+		return nil, domain_errors.ErrCampaignBudgetExceeded
+	}
+	// copy on write, so readers holding the previous value are not affected
+	updated := *campaign
+	updated.Spent += spent
+	updated.Reserved += reserved
+	s.campaigns[id] = &updated
+	return &updated, nil
+}
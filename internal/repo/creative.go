@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+)
+
+type GetCreativesFilter struct {
+	AdvertiserID string
+	Status       model.CreativeStatus
+}
+
+type CreativeRepository interface {
+	CreateCreative(creative *model.Creative) error
+	GetCreativeById(id string) (*model.Creative, error)
+	GetCreatives(filter GetCreativesFilter) ([]*model.Creative, error)
+	UpdateCreative(creative *model.Creative) error
+	IncrementStats(id string, eventType model.TrackingEventType) error
+	GetStats(id string) (model.CreativeStats, error)
+}
+
+var _ CreativeRepository = (*CreativeRepositoryImp)(nil)
+
+type CreativeRepositoryImp struct {
+	creatives map[string]*model.Creative
+	stats     map[string]*model.CreativeStats
+	mu        sync.RWMutex
+	log       *zap.SugaredLogger
+}
+
+func NewCreativeRepository(log *zap.SugaredLogger) CreativeRepository {
+	return &CreativeRepositoryImp{
+		creatives: make(map[string]*model.Creative),
+		stats:     make(map[string]*model.CreativeStats),
+		log:       log,
+	}
+}
+
+func (s *CreativeRepositoryImp) CreateCreative(creative *model.Creative) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.creatives[creative.ID] = creative
+	s.stats[creative.ID] = &model.CreativeStats{}
+	return nil
+}
+
+func (s *CreativeRepositoryImp) GetCreativeById(id string) (*model.Creative, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.creatives[id], nil
+}
+
+func (s *CreativeRepositoryImp) GetCreatives(filter GetCreativesFilter) ([]*model.Creative, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.Creative, 0)
+	for _, creative := range s.creatives {
+		if filter.AdvertiserID != "" && creative.AdvertiserID != filter.AdvertiserID {
+			continue
+		}
+		if filter.Status != "" && creative.Status != filter.Status {
+			continue
+		}
+		result = append(result, creative)
+	}
+	return result, nil
+}
+
+func (s *CreativeRepositoryImp) UpdateCreative(creative *model.Creative) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.creatives[creative.ID]; !ok {
+		return domain_errors.ErrCreativeNotFound
+	}
+	s.creatives[creative.ID] = creative
+	return nil
+}
+
+func (s *CreativeRepositoryImp) IncrementStats(id string, eventType model.TrackingEventType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.stats[id]
+	if !ok {
+		return domain_errors.ErrCreativeNotFound
+	}
+	switch eventType {
+	case model.TrackingEventTypeImpression:
+		stats.Impressions++
+	case model.TrackingEventTypeClick:
+		stats.Clicks++
+	default:
+		stats.VideoCounts.Add(eventType)
+	}
+	return nil
+}
+
+func (s *CreativeRepositoryImp) GetStats(id string) (model.CreativeStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats, ok := s.stats[id]
+	if !ok {
+		return model.CreativeStats{}, domain_errors.ErrCreativeNotFound
+	}
+	return *stats, nil
+}
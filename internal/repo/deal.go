@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+)
+
+type DealRepository interface {
+	CreateDeal(deal *model.Deal) error
+	GetDealById(id string) (*model.Deal, error)
+	GetDeals() ([]*model.Deal, error)
+}
+
+var _ DealRepository = (*DealRepositoryImp)(nil)
+
+type DealRepositoryImp struct {
+	deals map[string]*model.Deal
+	mu    sync.RWMutex
+	log   *zap.SugaredLogger
+}
+
+func NewDealRepository(log *zap.SugaredLogger) DealRepository {
+	return &DealRepositoryImp{
+		deals: make(map[string]*model.Deal),
+		log:   log,
+	}
+}
+
+// CreateDeal stores a new deal, failing with ErrDealAlreadyExists when the ID is taken
+func (s *DealRepositoryImp) CreateDeal(deal *model.Deal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.deals[deal.ID]; ok {
+		return domain_errors.ErrDealAlreadyExists
+	}
+	s.deals[deal.ID] = deal
+	return nil
+}
+
+func (s *DealRepositoryImp) GetDealById(id string) (*model.Deal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.deals[id], nil
+}
+
+func (s *DealRepositoryImp) GetDeals() ([]*model.Deal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.Deal, 0, len(s.deals))
+	for _, deal := range s.deals {
+		result = append(result, deal)
+	}
+	return result, nil
+}
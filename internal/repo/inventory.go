@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+)
+
+// GetInventoryFilter filters inventory counts by their hour, From is inclusive and To exclusive
+type GetInventoryFilter struct {
+	Placement string
+	From      time.Time
+	To        time.Time
+}
+
+type InventoryRepository interface {
+	// AddInventory adds the requests of count to the count of the same hour,
+	// placement and targeting attributes
+	AddInventory(count *model.InventoryCount) error
+	GetInventory(filter GetInventoryFilter) ([]*model.InventoryCount, error)
+	// DeleteBefore removes the counts of hours starting before t and returns their number
+	DeleteBefore(t time.Time) (int, error)
+}
+
+var _ InventoryRepository = (*InventoryRepositoryImp)(nil)
+
+// inventoryKey identifies the count of an hour of a placement
+type inventoryKey struct {
+	hour     time.Time
+	category string
+	keyword  string
+	domain   string
+	geo      model.Geo
+	device   model.Device
+	segments string
+}
+
+type InventoryRepositoryImp struct {
+	// counts are kept per placement, so memory grows with the distinct
+	// targeting attributes per hour rather than with the requests
+	counts map[string]map[inventoryKey]*model.InventoryCount
+	mu     sync.RWMutex
+	log    *zap.SugaredLogger
+}
+
+func NewInventoryRepository(log *zap.SugaredLogger) InventoryRepository {
+	return &InventoryRepositoryImp{
+		counts: make(map[string]map[inventoryKey]*model.InventoryCount),
+		log:    log,
+	}
+}
+
+func (s *InventoryRepositoryImp) AddInventory(count *model.InventoryCount) error {
+	segments := slices.Clone(count.Segments)
+	slices.Sort(segments)
+	key := inventoryKey{
+		hour:     count.Hour.UTC(),
+		category: count.Category,
+		keyword:  count.Keyword,
+		domain:   count.Domain,
+		geo:      count.Geo,
+		device:   count.Device,
+		segments: strings.Join(segments, "\x00"),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts, ok := s.counts[count.Placement]
+	if !ok {
+		counts = make(map[inventoryKey]*model.InventoryCount)
+		s.counts[count.Placement] = counts
+	}
+	// copy on write, so readers holding the previous value are not affected
+	updated := *count
+	updated.Hour = key.hour
+	updated.Segments = segments
+	if existing, ok := counts[key]; ok {
+		updated.Requests += existing.Requests
+	}
+	counts[key] = &updated
+	return nil
+}
+
+func (s *InventoryRepositoryImp) GetInventory(filter GetInventoryFilter) ([]*model.InventoryCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*model.InventoryCount
+	for placement, counts := range s.counts {
+		if filter.Placement != "" && placement != filter.Placement {
+			continue
+		}
+		for _, count := range counts {
+			if !filter.From.IsZero() && count.Hour.Before(filter.From) {
+				continue
+			}
+			if !filter.To.IsZero() && !count.Hour.Before(filter.To) {
+				continue
+			}
+			result = append(result, count)
+		}
+	}
+	return result, nil
+}
+
+func (s *InventoryRepositoryImp) DeleteBefore(t time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for placement, counts := range s.counts {
+		for key := range counts {
+			if key.hour.Before(t) {
+				delete(counts, key)
+				deleted++
+			}
+		}
+		if len(counts) == 0 {
+			delete(s.counts, placement)
+		}
+	}
+	return deleted, nil
+}
@@ -13,13 +13,22 @@ type GetLineItemsFilter struct {
 	Status       model.LineItemStatus
 	AdvertiserID string
 	Placement    string
+	Priority     model.LineItemPriority
 }
 
+// LineItemRepository stores line items. Stored line items are never modified
+// in place, so the line items it returns may be read without locking but must
+// not be modified.
 type LineItemRepository interface {
 	CreateLineItem(event *model.LineItem) error
 	GetLineItemById(id string) (*model.LineItem, error)
 	GetLineItems(filter GetLineItemsFilter) ([]*model.LineItem, error)
-	UpdateBudget(li *model.LineItem, newBudget float64) (*model.LineItem, error)
+	// UpdateBudget sets the budget and reservations of the line item, and fails
+	// with ErrLineItemAlreadyUpdated when li is not the stored version
+	UpdateBudget(li *model.LineItem, newBudget, newReserved float64) (*model.LineItem, error)
+	// UpdateLineItem replaces a stored line item. The budget and reservations
+	// are kept, as they are only changed through UpdateBudget.
+	UpdateLineItem(li *model.LineItem) error
 }
 
 var _ LineItemRepository = (*LineItemRepositoryImp)(nil)
@@ -67,19 +76,38 @@ func (s *LineItemRepositoryImp) GetLineItems(filter GetLineItemsFilter) ([]*mode
 		if filter.Placement != "" && item.Placement != filter.Placement {
 			continue
 		}
+		if filter.Priority != "" && item.Priority != filter.Priority {
+			continue
+		}
 		result = append(result, item)
 	}
 	return result, nil
 }
 
-func (s *LineItemRepositoryImp) UpdateBudget(li *model.LineItem, newBudget float64) (*model.LineItem, error) {
+func (s *LineItemRepositoryImp) UpdateBudget(li *model.LineItem, newBudget, newReserved float64) (*model.LineItem, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	item, ok := s.items[li.ID]
-	if !ok || item.Budget != li.Budget || !item.UpdatedAt.Equal(li.UpdatedAt) {
+	if !ok || item.Budget != li.Budget || item.Reserved != li.Reserved || !item.UpdatedAt.Equal(li.UpdatedAt) {
 		return nil, domain_errors.ErrLineItemAlreadyUpdated
 	}
-	item.Budget = newBudget
-	s.items[item.ID] = item
-	return item, nil
+	// copy on write, so readers holding the previous value are not affected
+	updated := *item
+	updated.Budget = newBudget
+	updated.Reserved = newReserved
+	s.items[li.ID] = &updated
+	return &updated, nil
+}
+
+func (s *LineItemRepositoryImp) UpdateLineItem(li *model.LineItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[li.ID]
+	if !ok {
+		return domain_errors.ErrLineItemNotFound
+	}
+	li.Budget = item.Budget
+	li.Reserved = item.Reserved
+	s.items[li.ID] = li
+	return nil
 }
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+)
+
+type SegmentRepository interface {
+	// AddMemberships adds or extends memberships, a membership never expires earlier than stored
+	AddMemberships(memberships []model.SegmentMembership) error
+	// GetUserSegments returns the memberships of the user which have not expired at t
+	GetUserSegments(userID string, t time.Time) ([]model.SegmentMembership, error)
+	// RemoveMembership removes a membership, returning whether it existed
+	RemoveMembership(userID, segmentID string) (bool, error)
+	// DeleteExpired removes memberships expired at t and returns their number
+	DeleteExpired(t time.Time) (int, error)
+}
+
+var _ SegmentRepository = (*SegmentRepositoryImp)(nil)
+
+type SegmentRepositoryImp struct {
+	// users maps user IDs to segment IDs and the expiry of the membership
+	users map[string]map[string]time.Time
+	mu    sync.RWMutex
+	log   *zap.SugaredLogger
+}
+
+func NewSegmentRepository(log *zap.SugaredLogger) SegmentRepository {
+	return &SegmentRepositoryImp{
+		users: make(map[string]map[string]time.Time),
+		log:   log,
+	}
+}
+
+func (s *SegmentRepositoryImp) AddMemberships(memberships []model.SegmentMembership) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range memberships {
+		segments, ok := s.users[m.UserID]
+		if !ok {
+			segments = make(map[string]time.Time)
+			s.users[m.UserID] = segments
+		}
+		if m.ExpiresAt.After(segments[m.SegmentID]) {
+			segments[m.SegmentID] = m.ExpiresAt
+		}
+	}
+	return nil
+}
+
+func (s *SegmentRepositoryImp) GetUserSegments(userID string, t time.Time) ([]model.SegmentMembership, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]model.SegmentMembership, 0, len(s.users[userID]))
+	for segmentID, expiresAt := range s.users[userID] {
+		if expiresAt.After(t) {
+			result = append(result, model.SegmentMembership{UserID: userID, SegmentID: segmentID, ExpiresAt: expiresAt})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SegmentID < result[j].SegmentID
+	})
+	return result, nil
+}
+
+func (s *SegmentRepositoryImp) RemoveMembership(userID, segmentID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments, ok := s.users[userID]
+	if !ok {
+		return false, nil
+	}
+	if _, ok := segments[segmentID]; !ok {
+		return false, nil
+	}
+	delete(segments, segmentID)
+	if len(segments) == 0 {
+		delete(s.users, userID)
+	}
+	return true, nil
+}
+
+func (s *SegmentRepositoryImp) DeleteExpired(t time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for userID, segments := range s.users {
+		for segmentID, expiresAt := range segments {
+			if !expiresAt.After(t) {
+				delete(segments, segmentID)
+				deleted++
+			}
+		}
+		if len(segments) == 0 {
+			delete(s.users, userID)
+		}
+	}
+	return deleted, nil
+}
@@ -1,35 +1,208 @@
 package repo
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
 	"sweng-task/internal/model"
 )
 
+type GetTrackingEventsFilter struct {
+	LineItemID string
+	EventType  model.TrackingEventType
+	Placement  string
+	UserID     string
+	IP         string
+	UserAgent  string
+	// From is inclusive, To is exclusive. Zero values are unbounded.
+	From time.Time
+	To   time.Time
+	// Offset is the storage position to resume scanning from
+	Offset int
+	Limit  int
+}
+
+type GetRollupsFilter struct {
+	LineItemID  string
+	Placement   string
+	Granularity model.RollupGranularity
+	From        time.Time
+	To          time.Time
+}
+
 type TrackingEventRepository interface {
 	CreateTrackingEvent(event *model.TrackingEvent) error
+	// GetTrackingEvents returns events matching filter in storage order, and the
+	// offset to resume from for the next page, which is 0 when there are no more events.
+	GetTrackingEvents(filter GetTrackingEventsFilter) ([]*model.TrackingEvent, int, error)
+	// IncrementRollups increments the rollup counters of event for every granularity
+	IncrementRollups(event *model.TrackingEvent, granularities ...model.RollupGranularity) error
+	// GetRollups returns copies of rollups matching filter, ordered by period and placement
+	GetRollups(filter GetRollupsFilter) ([]*model.Rollup, error)
+}
+
+type rollupKey struct {
+	lineItemID  string
+	placement   string
+	granularity model.RollupGranularity
+	period      time.Time
 }
 
 var _ TrackingEventRepository = (*TrackingEventRepositoryImp)(nil)
 
 type TrackingEventRepositoryImp struct {
 	events []*model.TrackingEvent
-	mu     sync.RWMutex
-	log    *zap.SugaredLogger
+	// byUser and byIP index positions of events in events by user ID and IP
+	byUser  map[string][]int
+	byIP    map[string][]int
+	rollups map[rollupKey]*model.Rollup
+	mu      sync.RWMutex
+	log     *zap.SugaredLogger
 }
 
 func NewTrackingEventRepository(log *zap.SugaredLogger) TrackingEventRepository {
 	return &TrackingEventRepositoryImp{
-		events: make([]*model.TrackingEvent, 0),
-		log:    log,
+		events:  make([]*model.TrackingEvent, 0),
+		byUser:  make(map[string][]int),
+		byIP:    make(map[string][]int),
+		rollups: make(map[rollupKey]*model.Rollup),
+		log:     log,
 	}
 }
 
 func (s *TrackingEventRepositoryImp) CreateTrackingEvent(event *model.TrackingEvent) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if event.UserID != "" {
+		s.byUser[event.UserID] = append(s.byUser[event.UserID], len(s.events))
+	}
+	if event.IP != "" {
+		s.byIP[event.IP] = append(s.byIP[event.IP], len(s.events))
+	}
 	s.events = append(s.events, event)
 	return nil
 }
+
+// indexPositions iterates the positions of an index from offset
+func indexPositions(positions []int, offset int) func(yield func(int) bool) {
+	return func(yield func(int) bool) {
+		for _, i := range positions {
+			if i >= offset && !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func (s *TrackingEventRepositoryImp) GetTrackingEvents(filter GetTrackingEventsFilter) ([]*model.TrackingEvent, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	positions := func(yield func(int) bool) {
+		for i := filter.Offset; i < len(s.events); i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	if filter.UserID != "" {
+		positions = indexPositions(s.byUser[filter.UserID], filter.Offset)
+	} else if filter.IP != "" {
+		positions = indexPositions(s.byIP[filter.IP], filter.Offset)
+	}
+
+	result := make([]*model.TrackingEvent, 0)
+	for i := range positions {
+		event := s.events[i]
+		if filter.LineItemID != "" && event.LineItemID != filter.LineItemID {
+			continue
+		}
+		if filter.EventType != "" && event.EventType != filter.EventType {
+			continue
+		}
+		if filter.Placement != "" && event.Placement != filter.Placement {
+			continue
+		}
+		if filter.UserID != "" && event.UserID != filter.UserID {
+			continue
+		}
+		if filter.IP != "" && event.IP != filter.IP {
+			continue
+		}
+		if filter.UserAgent != "" && event.UserAgent != filter.UserAgent {
+			continue
+		}
+		if !filter.From.IsZero() && event.Timestamp.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !event.Timestamp.Before(filter.To) {
+			continue
+		}
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			return result, i, nil
+		}
+		result = append(result, event)
+	}
+	return result, 0, nil
+}
+
+func (s *TrackingEventRepositoryImp) IncrementRollups(event *model.TrackingEvent, granularities ...model.RollupGranularity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range granularities {
+		key := rollupKey{
+			lineItemID:  event.LineItemID,
+			placement:   event.Placement,
+			granularity: g,
+			period:      g.Truncate(event.Timestamp),
+		}
+		rollup, ok := s.rollups[key]
+		if !ok {
+			rollup = &model.Rollup{
+				LineItemID:  key.lineItemID,
+				Placement:   key.placement,
+				Granularity: key.granularity,
+				Period:      key.period,
+			}
+			s.rollups[key] = rollup
+		}
+		rollup.Add(event.EventType)
+	}
+	return nil
+}
+
+func (s *TrackingEventRepositoryImp) GetRollups(filter GetRollupsFilter) ([]*model.Rollup, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.Rollup, 0)
+	for key, rollup := range s.rollups {
+		if filter.LineItemID != "" && key.lineItemID != filter.LineItemID {
+			continue
+		}
+		if filter.Placement != "" && key.placement != filter.Placement {
+			continue
+		}
+		if filter.Granularity != "" && key.granularity != filter.Granularity {
+			continue
+		}
+		if !filter.From.IsZero() && key.period.Before(filter.Granularity.Truncate(filter.From)) {
+			continue
+		}
+		if !filter.To.IsZero() && !key.period.Before(filter.To) {
+			continue
+		}
+		copied := *rollup
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Period.Equal(result[j].Period) {
+			return result[i].Period.Before(result[j].Period)
+		}
+		return result[i].Placement < result[j].Placement
+	})
+	return result, nil
+}
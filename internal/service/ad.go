@@ -2,20 +2,27 @@ package service
 
 import (
 	"errors"
+	"net/netip"
 	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
 	"sweng-task/internal/domain_errors"
 	"sweng-task/internal/model"
 	"sweng-task/internal/repo"
+	"sweng-task/internal/useragent"
 )
 
 // relevancy scoring helper struct
 type scoredItem struct {
 	*model.LineItem
-	score float64
+	priority int
+	tier     int
+	score    float64
 }
 
 // Scoring Weights
@@ -24,64 +31,373 @@ const (
 	wKeyword  = 0.2
 )
 
+// ScoringWeights are the relevancy score bonuses of line items matching the
+// category and keyword of the request. Zero weights are the default weights.
+type ScoringWeights struct {
+	Category float64
+	Keyword  float64
+}
+
+// DefaultScoringWeights are the default relevancy score bonuses
+var DefaultScoringWeights = ScoringWeights{Category: wCategory, Keyword: wKeyword}
+
+// FloorRules are the minimum bids per impression per placement, with a default
+// for unlisted placements. Requests with a higher bid floor keep their floor.
+type FloorRules struct {
+	Default    float64
+	Placements map[string]float64
+}
+
+func (r FloorRules) forPlacement(placement string) float64 {
+	if floor, ok := r.Placements[placement]; ok {
+		return floor
+	}
+	return r.Default
+}
+
+// SeparationRule limits which ads are returned together in one response
+type SeparationRule struct {
+	// MaxAdsPerAdvertiser is the maximum number of ads of one advertiser, zero means unlimited
+	MaxAdsPerAdvertiser int
+	// ExclusiveCategories are competitive advertiser categories, of which
+	// at most one advertiser per category is returned
+	ExclusiveCategories []string
+}
+
+// SeparationRules are the separation rules per placement, with a default for unlisted placements
+type SeparationRules struct {
+	Default    SeparationRule
+	Placements map[string]SeparationRule
+}
+
+// NewSeparationRules builds the per-placement separation rules, where placement
+// overrides fall back to the defaults for the settings they do not override.
+// Exclusive categories of placements are separated by "|".
+func NewSeparationRules(maxAdsPerAdvertiser int, exclusiveCategories []string, placementMaxAdsPerAdvertiser map[string]int, placementExclusiveCategories map[string]string) SeparationRules {
+	rules := SeparationRules{
+		Default: SeparationRule{
+			MaxAdsPerAdvertiser: maxAdsPerAdvertiser,
+			ExclusiveCategories: exclusiveCategories,
+		},
+		Placements: make(map[string]SeparationRule),
+	}
+	for placement, max := range placementMaxAdsPerAdvertiser {
+		rule, ok := rules.Placements[placement]
+		if !ok {
+			rule = rules.Default
+		}
+		rule.MaxAdsPerAdvertiser = max
+		rules.Placements[placement] = rule
+	}
+	for placement, categories := range placementExclusiveCategories {
+		rule, ok := rules.Placements[placement]
+		if !ok {
+			rule = rules.Default
+		}
+		rule.ExclusiveCategories = strings.Split(categories, "|")
+		rules.Placements[placement] = rule
+	}
+	return rules
+}
+
+func (r SeparationRules) forPlacement(placement string) SeparationRule {
+	if rule, ok := r.Placements[placement]; ok {
+		return rule
+	}
+	return r.Default
+}
+
+// adSeparation tracks the advertisers of the ads selected for one response
+type adSeparation struct {
+	rule        SeparationRule
+	advertisers map[string]int
+	categories  map[string]string
+}
+
+func newAdSeparation(rule SeparationRule) *adSeparation {
+	return &adSeparation{
+		rule:        rule,
+		advertisers: make(map[string]int),
+		categories:  make(map[string]string),
+	}
+}
+
+// allows reports whether an ad of the advertiser can be added to the response
+func (a *adSeparation) allows(advertiser *model.Advertiser) bool {
+	if a.rule.MaxAdsPerAdvertiser > 0 && a.advertisers[advertiser.ID] >= a.rule.MaxAdsPerAdvertiser {
+		return false
+	}
+	if owner, ok := a.categories[advertiser.Category]; ok && owner != advertiser.ID {
+		return false
+	}
+	return true
+}
+
+func (a *adSeparation) add(advertiser *model.Advertiser) {
+	a.advertisers[advertiser.ID]++
+	if advertiser.Category != "" && slices.Contains(a.rule.ExclusiveCategories, advertiser.Category) {
+		a.categories[advertiser.Category] = advertiser.ID
+	}
+}
+
 // AdService selects winning ads for placement
 type AdService struct {
 	lineItemService *LineItemService
 	lineItemRepo    repo.LineItemRepository
+	advertiserRepo  repo.AdvertiserRepository
+	campaignRepo    repo.CampaignRepository
+	creativeService *CreativeService
+	separation      SeparationRules
+	weights         ScoringWeights
+	floors          FloorRules
+	geo             GeoLocator
+	segments        *SegmentService
+	deals           *DealService
+	delivery        *DeliveryService
+	requests        *AdRequestLog
+	now             func() time.Time
 	log             *zap.SugaredLogger
+
+	servedMu sync.Mutex
+	// served is the spend of served ads per line item which is not settled
+	// by an impression event yet
+	served map[string]*servedSpend
+}
+
+// servedSpend is the spend of ads served without an impression event yet
+type servedSpend struct {
+	ads    int64
+	amount float64
+}
+
+// AdServiceDeps are the repositories and services ads are selected with
+type AdServiceDeps struct {
+	LineItemRepo    repo.LineItemRepository
+	LineItemService *LineItemService
+	AdvertiserRepo  repo.AdvertiserRepository
+	CampaignRepo    repo.CampaignRepository
+	CreativeService *CreativeService
+	// Geo may be nil when no GeoIP database is configured
+	Geo      GeoLocator
+	Segments *SegmentService
+	Deals    *DealService
+	Delivery *DeliveryService
+	Requests *AdRequestLog
 }
 
-func NewAdService(lineItemRepo repo.LineItemRepository, lineItemService *LineItemService, log *zap.SugaredLogger) *AdService {
+// AdSelectionRules configure how matching line items are ranked and served
+type AdSelectionRules struct {
+	Separation SeparationRules
+	Weights    ScoringWeights
+	Floors     FloorRules
+}
+
+// NewAdService creates a new AdService
+func NewAdService(deps AdServiceDeps, rules AdSelectionRules, log *zap.SugaredLogger) *AdService {
 	return &AdService{
-		lineItemService: lineItemService,
-		lineItemRepo:    lineItemRepo,
+		lineItemService: deps.LineItemService,
+		lineItemRepo:    deps.LineItemRepo,
+		advertiserRepo:  deps.AdvertiserRepo,
+		campaignRepo:    deps.CampaignRepo,
+		creativeService: deps.CreativeService,
+		separation:      rules.Separation,
+		weights:         rules.Weights,
+		floors:          rules.Floors,
+		geo:             deps.Geo,
+		segments:        deps.Segments,
+		deals:           deps.Deals,
+		delivery:        deps.Delivery,
+		requests:        deps.Requests,
+		now:             time.Now,
 		log:             log,
+		served:          make(map[string]*servedSpend),
 	}
 }
 
+// GeoLocator resolves the location of an IP address
+type GeoLocator interface {
+	Lookup(ip netip.Addr) (model.Geo, bool)
+}
+
 type AdQuery struct {
 	Placement string
 	Category  string
 	Keyword   string
-	Limit     int
+	// Domain is the page domain of the ad request, matched against blocklists
+	Domain string
+	Limit  int
+
+	// Geo and Device describe the request for targeting. Unknown values are
+	// derived from the IP and UserAgent of the request.
+	Geo       model.Geo
+	Device    model.Device
+	IP        string
+	UserAgent string
+
+	// UserID identifies the user for audience segment targeting, Segments are
+	// the segments of the user and are looked up when not given
+	UserID   string
+	Segments []string
+
+	// Constraints of external auctions. BidFloor is the minimum bid per impression,
+	// Formats are the allowed creative formats, Sizes the allowed "<width>x<height>"
+	// sizes of non-native creatives, and advertisers with blocked domains or
+	// categories are not returned. Empty constraints allow everything.
+	BidFloor                    float64
+	Formats                     []model.CreativeFormat
+	Sizes                       []string
+	BlockedAdvertiserDomains    []string
+	BlockedAdvertiserCategories []string
+
+	// DealIDs are the private marketplace deals offered by the request, only
+	// deal line items are eligible on private auctions
+	DealIDs        []string
+	PrivateAuction bool
+	// ExcludeHouse excludes house line items, which are not sold
+	ExcludeHouse bool
+}
+
+// blocksAdvertiser reports whether the advertiser is blocked by the request
+func (q *AdQuery) blocksAdvertiser(advertiser *model.Advertiser) bool {
+	return slices.ContainsFunc(q.BlockedAdvertiserDomains, func(domain string) bool {
+		return strings.EqualFold(domain, advertiser.Domain)
+	}) || advertiser.Category != "" && slices.ContainsFunc(q.BlockedAdvertiserCategories, func(category string) bool {
+		return strings.EqualFold(category, advertiser.Category)
+	})
+}
+
+// constrainsCreatives reports whether the request restricts creative formats or sizes
+func (q *AdQuery) constrainsCreatives() bool {
+	return len(q.Formats) > 0 || len(q.Sizes) > 0
+}
+
+// resolveContext fills the location and device of the request which were not
+// given explicitly from the GeoIP database and the User-Agent, and the segments
+// of the user from the segment store
+func (s *AdService) resolveContext(q *AdQuery) error {
+	if q.IP != "" && s.geo != nil && (q.Geo.Country == "" || q.Geo.Region == "" || q.Geo.City == "") {
+		if ip, err := netip.ParseAddr(q.IP); err == nil {
+			if geo, ok := s.geo.Lookup(ip); ok {
+				fillGeo(&q.Geo, geo)
+			}
+		}
+	}
+	if q.UserAgent != "" && (q.Device.Type == "" || q.Device.OS == "" || q.Device.Browser == "") {
+		device := useragent.Parse(q.UserAgent)
+		if q.Device.Type == "" {
+			q.Device.Type = device.Type
+		}
+		if q.Device.OS == "" {
+			q.Device.OS = device.OS
+		}
+		if q.Device.Browser == "" {
+			q.Device.Browser = device.Browser
+		}
+	}
+	if q.Segments == nil && q.UserID != "" && s.segments != nil {
+		segments, err := s.segments.GetUserSegments(q.UserID)
+		if err != nil {
+			return err
+		}
+		q.Segments = segments
+	}
+	return nil
+}
+
+// fillGeo fills the empty fields of an explicit location from the location of
+// the IP, unless the explicit country or region is elsewhere
+func fillGeo(explicit *model.Geo, located model.Geo) {
+	if explicit.Country != "" && !strings.EqualFold(explicit.Country, located.Country) {
+		return
+	}
+	explicit.Country = located.Country
+	if explicit.Region != "" && !strings.EqualFold(explicit.Region, located.Region) {
+		return
+	}
+	explicit.Region = located.Region
+	if explicit.City == "" {
+		explicit.City = located.City
+	}
 }
 
+// GetWinningAds returns up to q.Limit ads in ranking order, spending their bids
+// from the line item, advertiser and campaign budgets
 func (s *AdService) GetWinningAds(q AdQuery) ([]*model.Ad, error) {
-	lineItems, err := s.lineItemService.FindMatchingLineItems(q.Placement, q.Category, q.Keyword)
+	return s.selectAds(q, s.spendBudgets)
+}
+
+// selectAds walks the ranking of line items matching q and returns up to q.Limit
+// ads, of line items for which serve succeeds. House line items fill the slots
+// left by the other tiers. The separation rules of the
+// placement are applied while walking the ranking, so a candidate which cannot
+// be served does not exclude its competitors. Sampled requests are logged with
+// their candidates and winners.
+func (s *AdService) selectAds(q AdQuery, serve func(lineItem *model.LineItem) (*model.LineItem, bool)) ([]*model.Ad, error) {
+	if err := s.resolveContext(&q); err != nil {
+		return nil, err
+	}
+	lineItems, excluded, err := s.lineItemService.FindMatchingLineItems(q)
 	if err != nil {
 		return nil, err
 	}
-	lineItems = s.winningAdCalculator(q, lineItems)
+	ranked := s.rank(q, lineItems)
+	floor := max(q.BidFloor, s.floors.forPlacement(q.Placement))
 
+	separation := newAdSeparation(s.separation.forPlacement(q.Placement))
 	var result []*model.Ad
-	for _, lineItem := range lineItems {
-		if lineItem.Budget < lineItem.Bid {
+	for _, item := range ranked {
+		lineItem := item.LineItem
+		house := lineItem.Priority == model.LineItemPriorityHouse
+		if house && q.ExcludeHouse {
 			continue
 		}
-		updatedLineItem, err := s.lineItemRepo.UpdateBudget(lineItem, lineItem.Budget-lineItem.Bid)
-		if err != nil || updatedLineItem == nil {
-			if !errors.Is(err, domain_errors.ErrLineItemAlreadyUpdated) {
-				s.log.Warnw("line item is already updated before budget spending",
-					"id", lineItem.ID)
-			} else {
-				s.log.Errorw("error in updating line item budget spending",
-					"id", lineItem.ID)
-			}
+		if !house && (lineItem.Budget-lineItem.Reserved < lineItem.Bid || lineItem.Bid < floor) {
+			continue
+		}
+		advertiser, err := s.advertiserRepo.GetAdvertiserById(lineItem.AdvertiserID)
+		if err != nil || advertiser == nil || advertiser.Status != model.AdvertiserStatusActive {
 			continue
 		}
+		if q.blocksAdvertiser(advertiser) || !separation.allows(advertiser) {
+			continue
+		}
+		creative, err := s.creativeService.Select(lineItem, q.Formats, q.Sizes)
+		if err != nil {
+			s.log.Errorw("error in selecting creative",
+				"id", lineItem.ID,
+				"error", err)
+		}
+		if creative == nil && q.constrainsCreatives() {
+			continue
+		}
+		// house ads are free, so they are served without spending budgets
+		servedLineItem := lineItem
+		if !house {
+			var ok bool
+			if servedLineItem, ok = serve(lineItem); !ok {
+				continue
+			}
+		}
+		separation.add(advertiser)
 		result = append(result, &model.Ad{
-			ID:           updatedLineItem.ID,
-			Name:         updatedLineItem.Name,
-			AdvertiserID: updatedLineItem.AdvertiserID,
-			Bid:          updatedLineItem.Bid,
-			Placement:    updatedLineItem.Placement,
-			ServeURL:     serveUrlGenerator(updatedLineItem),
+			ID:           servedLineItem.ID,
+			Name:         servedLineItem.Name,
+			AdvertiserID: servedLineItem.AdvertiserID,
+			Bid:          servedLineItem.Bid,
+			Placement:    servedLineItem.Placement,
+			ServeURL:     serveUrlGenerator(servedLineItem),
+			Creative:     creative,
+			DealID:       servedLineItem.DealID,
 		})
 		if len(result) >= q.Limit {
 			break
 		}
 	}
 
+	if s.requests.sampled() {
+		s.requests.record(newAdRequest(q, s.now(), len(lineItems), excluded, ranked, result))
+	}
+
 	var ids []string
 	for _, ad := range result {
 		ids = append(ids, ad.ID)
@@ -90,45 +406,246 @@ func (s *AdService) GetWinningAds(q AdQuery) ([]*model.Ad, error) {
 		"placement", q.Placement,
 		"category", q.Category,
 		"keyword", q.Keyword,
+		"domain", q.Domain,
+		"country", q.Geo.Country,
+		"device_type", q.Device.Type,
+		"segments", len(q.Segments),
 		"limit", q.Limit,
 		"candidates", len(lineItems),
+		"excluded", excluded,
 		"returned", len(result),
 		"ad_ids", ids,
 	)
 	return result, nil
 }
 
+// spendBudgets spends the bid of the line item from its own and account
+// budgets, until the impression of the ad is settled by SettleImpression
+func (s *AdService) spendBudgets(lineItem *model.LineItem) (*model.LineItem, bool) {
+	servedLineItem, ok := s.chargeBudgets(lineItem, lineItem.Bid, 0)
+	if ok {
+		s.servedMu.Lock()
+		spend, found := s.served[lineItem.ID]
+		if !found {
+			spend = &servedSpend{}
+			s.served[lineItem.ID] = spend
+		}
+		spend.ads++
+		spend.amount += lineItem.Bid
+		s.servedMu.Unlock()
+	}
+	return servedLineItem, ok
+}
+
+// SettleImpression settles the spend of a served ad of the line item with its
+// impression event, which is charged the spend as its cost. The spend of
+// impressions flagged as invalid traffic is refunded, so invalid traffic is not billed. Impression events beyond the
+// served ads are not refunded, so refunds never exceed the spend.
+func (s *AdService) SettleImpression(event *model.TrackingEvent) {
+	if event.EventType != model.TrackingEventTypeImpression || event.LineItemID == "" {
+		return
+	}
+	s.servedMu.Lock()
+	spend, ok := s.served[event.LineItemID]
+	if !ok {
+		s.servedMu.Unlock()
+		return
+	}
+	amount := spend.amount / float64(spend.ads)
+	spend.ads--
+	spend.amount -= amount
+	if spend.ads == 0 {
+		delete(s.served, event.LineItemID)
+	}
+	s.servedMu.Unlock()
+
+	if event.IsValid() {
+		event.Cost = amount
+		return
+	}
+	if err := s.refundBudgets(event.LineItemID, amount); err != nil {
+		s.log.Errorw("error in refunding invalid impression",
+			"line_item_id", event.LineItemID,
+			"invalid_reason", event.InvalidReason,
+			"error", err)
+	}
+}
+
+// reserveBudgets reserves the bid of the line item from its own and account
+// budgets, until it is committed or released through adjustBudgets
+func (s *AdService) reserveBudgets(lineItem *model.LineItem) (*model.LineItem, bool) {
+	return s.chargeBudgets(lineItem, 0, lineItem.Bid)
+}
+
+// chargeBudgets spends and reserves the given amounts from the line item and
+// account budgets, and reports whether the line item can be served
+func (s *AdService) chargeBudgets(lineItem *model.LineItem, spent, reserved float64) (*model.LineItem, bool) {
+	if !s.spendAccountBudgets(lineItem, spent, reserved) {
+		return nil, false
+	}
+	updatedLineItem, err := s.lineItemRepo.UpdateBudget(lineItem, lineItem.Budget-spent, lineItem.Reserved+reserved)
+	if err != nil || updatedLineItem == nil {
+		s.refundAccountBudgets(lineItem, spent, reserved)
+		if !errors.Is(err, domain_errors.ErrLineItemAlreadyUpdated) {
+			s.log.Warnw("line item is already updated before budget spending",
+				"id", lineItem.ID)
+		} else {
+			s.log.Errorw("error in updating line item budget spending",
+				"id", lineItem.ID)
+		}
+		return nil, false
+	}
+	return updatedLineItem, true
+}
+
+// refundBudgets returns amount to the line item, advertiser and campaign budgets
+func (s *AdService) refundBudgets(lineItemID string, amount float64) error {
+	return s.adjustBudgets(lineItemID, -amount, 0)
+}
+
+// adjustBudgets adds spent to the spend and reserved to the reservations of the
+// line item, advertiser and campaign without checking their budgets. Committing
+// a reservation spends its price and releases the reservation in one step.
+func (s *AdService) adjustBudgets(lineItemID string, spent, reserved float64) error {
+	for {
+		lineItem, err := s.lineItemRepo.GetLineItemById(lineItemID)
+		if err != nil {
+			return err
+		}
+		if lineItem == nil {
+			return domain_errors.ErrLineItemNotFound
+		}
+		_, err = s.lineItemRepo.UpdateBudget(lineItem, lineItem.Budget-spent, max(lineItem.Reserved+reserved, 0))
+		if errors.Is(err, domain_errors.ErrLineItemAlreadyUpdated) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		// refunding the negated amounts adds them to the account budgets
+		s.refundAccountBudgets(lineItem, -spent, -reserved)
+		return nil
+	}
+}
+
 func (s *AdService) winningAdCalculator(q AdQuery, lineItems []*model.LineItem) []*model.LineItem {
-	if len(lineItems) == 0 {
-		return []*model.LineItem{}
+	scoredItems := s.rank(q, lineItems)
+	result := make([]*model.LineItem, len(scoredItems))
+	for i, item := range scoredItems {
+		result[i] = item.LineItem
 	}
+	return result
+}
 
-	scoredItems := make([]scoredItem, len(lineItems))
-	for i, lineItem := range lineItems {
-		scoredItems[i] = scoredItem{
+// rank scores the line items for the request and returns them in ranking order,
+// without guaranteed line items which sit out the request to pace their delivery
+func (s *AdService) rank(q AdQuery, lineItems []*model.LineItem) []scoredItem {
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	weights := s.weights
+	if weights == (ScoringWeights{}) {
+		weights = DefaultScoringWeights
+	}
+	scoredItems := make([]scoredItem, 0, len(lineItems))
+	for _, lineItem := range lineItems {
+		item := scoredItem{
 			LineItem: lineItem,
-			score:    lineItem.Bid * relevancyScore(lineItem, q),
+			priority: lineItem.Priority.Rank(),
+			tier:     s.deals.tier(lineItem),
+			score:    lineItem.Bid * relevancyScore(lineItem, q, weights),
+		}
+		switch lineItem.Priority {
+		case model.LineItemPriorityGuaranteed:
+			// guaranteed line items are paced over their flight
+			participates, boost := s.delivery.pacing(lineItem, now)
+			if !participates {
+				continue
+			}
+			item.score *= boost
+		case model.LineItemPriorityHouse:
+			// house ads do not bid, so they rank by relevancy alone
+			item.score = relevancyScore(lineItem, q, weights)
 		}
+		scoredItems = append(scoredItems, item)
 	}
 
-	// Sort line items by descending score
-	sort.Slice(lineItems, func(i, j int) bool {
+	// Sort line items by priority, deal priority tier, then by their own scores,
+	// descending. The scored items are sorted, so every score moves with its line item.
+	sort.SliceStable(scoredItems, func(i, j int) bool {
+		if scoredItems[i].priority != scoredItems[j].priority {
+			return scoredItems[i].priority < scoredItems[j].priority
+		}
+		if scoredItems[i].tier != scoredItems[j].tier {
+			return scoredItems[i].tier < scoredItems[j].tier
+		}
 		return scoredItems[i].score > scoredItems[j].score
 	})
-	return lineItems
+	return scoredItems
+}
+
+// spendAccountBudgets spends and reserves the given amounts from the budgets
+// above the line item, the advertiser budget cap and the campaign budget, and
+// reports whether the line item can be served. Nothing is spent when any
+// budget is exhausted.
+func (s *AdService) spendAccountBudgets(lineItem *model.LineItem, spent, reserved float64) bool {
+	if _, err := s.advertiserRepo.AddSpend(lineItem.AdvertiserID, spent, reserved); err != nil {
+		if !errors.Is(err, domain_errors.ErrAdvertiserBudgetExceeded) {
+			s.log.Errorw("error in spending advertiser budget",
+				"advertiser_id", lineItem.AdvertiserID,
+				"error", err)
+		}
+		return false
+	}
+	if lineItem.CampaignID == "" {
+		return true
+	}
+	if _, err := s.campaignRepo.AddSpend(lineItem.CampaignID, spent, reserved); err != nil {
+		if !errors.Is(err, domain_errors.ErrCampaignBudgetExceeded) {
+			s.log.Errorw("error in spending campaign budget",
+				"campaign_id", lineItem.CampaignID,
+				"error", err)
+		}
+		s.refundAdvertiserBudget(lineItem, spent, reserved)
+		return false
+	}
+	return true
+}
+
+// refundAccountBudgets returns the given spend and reservations to the
+// advertiser and campaign budgets
+func (s *AdService) refundAccountBudgets(lineItem *model.LineItem, spent, reserved float64) {
+	s.refundAdvertiserBudget(lineItem, spent, reserved)
+	if lineItem.CampaignID == "" {
+		return
+	}
+	if _, err := s.campaignRepo.AddSpend(lineItem.CampaignID, -spent, -reserved); err != nil {
+		s.log.Errorw("error in refunding campaign budget",
+			"campaign_id", lineItem.CampaignID,
+			"error", err)
+	}
+}
+
+func (s *AdService) refundAdvertiserBudget(lineItem *model.LineItem, spent, reserved float64) {
+	if _, err := s.advertiserRepo.AddSpend(lineItem.AdvertiserID, -spent, -reserved); err != nil {
+		s.log.Errorw("error in refunding advertiser budget",
+			"advertiser_id", lineItem.AdvertiserID,
+			"error", err)
+	}
 }
 
 func serveUrlGenerator(li *model.LineItem) string {
 	return "/ad/serve/" + li.ID
 }
 
-func relevancyScore(lineItem *model.LineItem, q AdQuery) float64 {
+func relevancyScore(lineItem *model.LineItem, q AdQuery, weights ScoringWeights) float64 {
 	score := 1.0
 	if q.Category != "" && slices.Contains(lineItem.Categories, q.Category) {
-		score += wCategory
+		score += weights.Category
 	}
 	if q.Keyword != "" && slices.Contains(lineItem.Keywords, q.Keyword) {
-		score += wKeyword
+		score += weights.Keyword
 	}
 	return score
 }
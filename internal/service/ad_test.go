@@ -1,15 +1,24 @@
 package service
 
 import (
+	"net/netip"
 	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"go.uber.org/zap"
 
 	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
 )
 
 func TestAdService_winningAdCalculator(t *testing.T) {
 	li1 := &model.LineItem{ID: "1", Name: "1", AdvertiserID: "1", Bid: 10, Budget: 0, Placement: "pl1", Categories: nil, Keywords: nil}
 	li2 := &model.LineItem{ID: "2", Name: "2", AdvertiserID: "2", Bid: 20, Budget: 0, Placement: "pl2", Categories: nil, Keywords: nil}
+	li3 := &model.LineItem{ID: "3", Name: "3", AdvertiserID: "3", Bid: 15, Budget: 0, Placement: "pl1", Categories: []string{"sports"}, Keywords: nil}
 
 	type args struct {
 		q         AdQuery
@@ -44,6 +53,15 @@ func TestAdService_winningAdCalculator(t *testing.T) {
 			},
 			want: []*model.LineItem{li2, li1},
 		},
+		{
+			// three or more line items are ordered by their own scores, descending
+			name: "winningAdCalculator ranks by bid weighted with relevancy",
+			args: args{
+				q:         AdQuery{Placement: "", Category: "sports", Keyword: "", Limit: 3},
+				lineItems: []*model.LineItem{li1, li3, li2},
+			},
+			want: []*model.LineItem{li2, li3, li1},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -67,3 +85,411 @@ func isAdSlicesEqual(got []*model.LineItem, want []*model.LineItem) bool {
 	}
 	return true
 }
+
+type testRepos struct {
+	lineItems   repo.LineItemRepository
+	advertisers repo.AdvertiserRepository
+	campaigns   repo.CampaignRepository
+	creatives   repo.CreativeRepository
+	segments    repo.SegmentRepository
+	audiences   repo.AudienceRepository
+	tracking    repo.TrackingEventRepository
+	deals       repo.DealRepository
+	inventory   repo.InventoryRepository
+}
+
+func newTestAdService() (*AdService, testRepos) {
+	return newTestAdServiceWithSeparation(SeparationRules{})
+}
+
+// newTestRepos returns empty repositories, for tests which only need stored
+// line items, accounts or events
+func newTestRepos() testRepos {
+	log := zap.NewNop().Sugar()
+	return testRepos{
+		lineItems:   repo.NewLineItemRepository(log),
+		advertisers: repo.NewAdvertiserRepository(log),
+		campaigns:   repo.NewCampaignRepository(log),
+		creatives:   repo.NewCreativeRepository(log),
+		segments:    repo.NewSegmentRepository(log),
+		audiences:   repo.NewAudienceRepository(log),
+		tracking:    repo.NewTrackingEventRepository(log),
+		deals:       repo.NewDealRepository(log),
+		inventory:   repo.NewInventoryRepository(log),
+	}
+}
+
+func newTestAdServiceWithSeparation(separation SeparationRules) (*AdService, testRepos) {
+	log := zap.NewNop().Sugar()
+	r := newTestRepos()
+	advertiserService := NewAdvertiserService(r.advertisers, log)
+	campaignService := NewCampaignService(r.campaigns, advertiserService, log)
+	creativeService := NewCreativeService(r.creatives, advertiserService, CreativePolicy{}, log)
+	segmentService := NewSegmentService(r.segments, time.Hour, log)
+	audienceService := NewAudienceService(r.audiences, r.tracking, r.lineItems, advertiserService, segmentService, log)
+	dealService := NewDealService(r.deals, []model.DealPriceType{model.DealPriceTypeFixed, model.DealPriceTypeFloor}, log)
+	lineItemService := NewLineItemService(r.lineItems, advertiserService, campaignService, creativeService, audienceService, dealService, log)
+	deliveryService := NewDeliveryService(r.lineItems, r.tracking, PacingRules{MaxBoost: 4, UnderdeliveryTolerance: 0.1}, nil, log)
+	return NewAdService(AdServiceDeps{
+		LineItemRepo:    r.lineItems,
+		LineItemService: lineItemService,
+		AdvertiserRepo:  r.advertisers,
+		CampaignRepo:    r.campaigns,
+		CreativeService: creativeService,
+		Segments:        segmentService,
+		Deals:           dealService,
+		Delivery:        deliveryService,
+		Requests:        NewAdRequestLog(r.inventory, nil, 1, log),
+	}, AdSelectionRules{Separation: separation, Weights: DefaultScoringWeights}, log), r
+}
+
+// addServableLineItem stores the line item with an approved creative attached
+func addServableLineItem(r testRepos, lineItem *model.LineItem) {
+	creative := &model.Creative{
+		ID:           "cr_" + lineItem.ID,
+		AdvertiserID: lineItem.AdvertiserID,
+		Status:       model.CreativeStatusApproved,
+	}
+	_ = r.creatives.CreateCreative(creative)
+	lineItem.Creatives = append(lineItem.Creatives, model.LineItemCreative{CreativeID: creative.ID, Weight: 1})
+	_ = r.lineItems.CreateLineItem(lineItem)
+}
+
+// assertServed requests one ad per expected line item ID, empty IDs expecting no fill
+func assertServed(t *testing.T, s *AdService, q AdQuery, want ...string) {
+	t.Helper()
+	for i, id := range want {
+		ads, err := s.GetWinningAds(q)
+		if err != nil {
+			t.Fatalf("request %d: GetWinningAds() error = %v", i, err)
+		}
+		got := ""
+		if len(ads) > 0 {
+			got = ads[0].ID
+		}
+		if got != id {
+			t.Errorf("request %d: served %q, want %q", i, got, id)
+		}
+	}
+}
+
+func TestAdService_GetWinningAds_AdvertiserBudget(t *testing.T) {
+	s, r := newTestAdService()
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_capped", Status: model.AdvertiserStatusActive, BudgetCap: 25})
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_suspended", Status: model.AdvertiserStatusSuspended})
+	addServableLineItem(r, &model.LineItem{ID: "li_capped", AdvertiserID: "adv_capped", Bid: 10, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_suspended", AdvertiserID: "adv_suspended", Bid: 50, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+
+	assertServed(t, s, AdQuery{Placement: "top", Limit: 1}, "li_capped", "li_capped", "")
+
+	advertiser, _ := r.advertisers.GetAdvertiserById("adv_capped")
+	if advertiser.Spent != 20 {
+		t.Errorf("advertiser spent = %v, want 20", advertiser.Spent)
+	}
+}
+
+func TestAdService_GetWinningAds_Concurrent(t *testing.T) {
+	s, r := newTestAdService()
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_1", AdvertiserID: "adv_1", Bid: 1, Budget: 200, Placement: "top", Status: model.LineItemStatusActive})
+
+	// every served ad is charged exactly once, however the requests interleave
+	var served atomic.Int64
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				ads, err := s.GetWinningAds(AdQuery{Placement: "top", Limit: 1})
+				if err != nil {
+					t.Errorf("GetWinningAds() error = %v", err)
+				}
+				served.Add(int64(len(ads)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	lineItem, _ := r.lineItems.GetLineItemById("li_1")
+	advertiser, _ := r.advertisers.GetAdvertiserById("adv_1")
+	if n := float64(served.Load()); n == 0 || n > 200 || lineItem.Budget != 200-n || advertiser.Spent != n {
+		t.Errorf("served %v ads, budget = %v, advertiser spent = %v, want every served ad charged once", n, lineItem.Budget, advertiser.Spent)
+	}
+}
+
+func TestAdService_GetWinningAds_Campaign(t *testing.T) {
+	s, r := newTestAdService()
+	now := time.Now()
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	_ = r.campaigns.CreateCampaign(&model.Campaign{ID: "cmp_1", AdvertiserID: "adv_1", Budget: 15, Status: model.CampaignStatusActive, StartDate: now.Add(-time.Hour), EndDate: now.Add(time.Hour)})
+	_ = r.campaigns.CreateCampaign(&model.Campaign{ID: "cmp_paused", AdvertiserID: "adv_1", Budget: 100, Status: model.CampaignStatusPaused, StartDate: now.Add(-time.Hour), EndDate: now.Add(time.Hour)})
+	_ = r.campaigns.CreateCampaign(&model.Campaign{ID: "cmp_ended", AdvertiserID: "adv_1", Budget: 100, Status: model.CampaignStatusActive, StartDate: now.Add(-2 * time.Hour), EndDate: now.Add(-time.Hour)})
+	addServableLineItem(r, &model.LineItem{ID: "li_1", AdvertiserID: "adv_1", CampaignID: "cmp_1", Bid: 10, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_paused", AdvertiserID: "adv_1", CampaignID: "cmp_paused", Bid: 20, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_ended", AdvertiserID: "adv_1", CampaignID: "cmp_ended", Bid: 30, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+
+	// campaign budget allows a single bid although the line item has budget left
+	assertServed(t, s, AdQuery{Placement: "top", Limit: 1}, "li_1", "")
+
+	advertiser, _ := r.advertisers.GetAdvertiserById("adv_1")
+	if advertiser.Spent != 10 {
+		t.Errorf("advertiser spent = %v, want 10 after refused campaign spend", advertiser.Spent)
+	}
+}
+
+func TestAdService_GetWinningAds_Separation(t *testing.T) {
+	addLineItems := func(r testRepos) {
+		_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_car1", Category: "automotive", Status: model.AdvertiserStatusActive})
+		_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_car2", Category: "automotive", Status: model.AdvertiserStatusActive})
+		_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_food", Category: "food", Status: model.AdvertiserStatusActive})
+		addServableLineItem(r, &model.LineItem{ID: "li_car1_a", AdvertiserID: "adv_car1", Bid: 50, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+		addServableLineItem(r, &model.LineItem{ID: "li_car1_b", AdvertiserID: "adv_car1", Bid: 40, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+		addServableLineItem(r, &model.LineItem{ID: "li_car2", AdvertiserID: "adv_car2", Bid: 30, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+		addServableLineItem(r, &model.LineItem{ID: "li_food", AdvertiserID: "adv_food", Bid: 20, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+		addServableLineItem(r, &model.LineItem{ID: "li_car1_side", AdvertiserID: "adv_car1", Bid: 50, Budget: 100, Placement: "side", Status: model.LineItemStatusActive})
+		addServableLineItem(r, &model.LineItem{ID: "li_car2_side", AdvertiserID: "adv_car2", Bid: 40, Budget: 100, Placement: "side", Status: model.LineItemStatusActive})
+	}
+	separation := SeparationRules{
+		Default: SeparationRule{MaxAdsPerAdvertiser: 1, ExclusiveCategories: []string{"automotive"}},
+		Placements: map[string]SeparationRule{
+			"side": {MaxAdsPerAdvertiser: 2},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		separation SeparationRules
+		placement  string
+		want       []string
+	}{
+		{"without rules ads are ranked by bid", SeparationRules{}, "top", []string{"li_car1_a", "li_car1_b", "li_car2"}},
+		{"one ad per advertiser and category", separation, "top", []string{"li_car1_a", "li_food"}},
+		{"placement rule overrides default", separation, "side", []string{"li_car1_side", "li_car2_side"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, r := newTestAdServiceWithSeparation(tt.separation)
+			addLineItems(r)
+
+			ads, err := s.GetWinningAds(AdQuery{Placement: tt.placement, Limit: 3})
+			if err != nil {
+				t.Fatalf("GetWinningAds() error = %v", err)
+			}
+			var got []string
+			for _, ad := range ads {
+				got = append(got, ad.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetWinningAds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type stubGeoLocator map[string]model.Geo
+
+func (g stubGeoLocator) Lookup(ip netip.Addr) (model.Geo, bool) {
+	geo, ok := g[ip.String()]
+	return geo, ok
+}
+
+func TestAdService_GetWinningAds_Targeting(t *testing.T) {
+	iphone := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+	tests := []struct {
+		name string
+		q    AdQuery
+		want string
+	}{
+		{"explicit geo and device", AdQuery{Geo: model.Geo{Country: "de"}, Device: model.Device{Type: model.DeviceTypeMobile, OS: "iOS"}}, "li_de_ios"},
+		{"geo from ip and device from user agent", AdQuery{IP: "192.0.2.1", UserAgent: iphone}, "li_de_ios"},
+		{"explicit geo overrides ip", AdQuery{IP: "192.0.2.1", UserAgent: iphone, Geo: model.Geo{Country: "FR"}}, "li_any"},
+		{"explicit region is kept and the country filled from ip", AdQuery{IP: "192.0.2.1", Geo: model.Geo{Region: "BY"}}, "li_bavaria"},
+		{"unknown context only matches untargeted line items", AdQuery{}, "li_any"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, r := newTestAdService()
+			s.geo = stubGeoLocator{"192.0.2.1": {Country: "DE", Region: "BE", City: "Berlin"}}
+			_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+			addServableLineItem(r, &model.LineItem{ID: "li_de_ios", AdvertiserID: "adv_1", Bid: 20, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+				Targeting: model.Targeting{Countries: []string{"DE"}, DeviceTypes: []model.DeviceType{model.DeviceTypeMobile}, OS: []string{"ios"}}})
+			addServableLineItem(r, &model.LineItem{ID: "li_bavaria", AdvertiserID: "adv_1", Bid: 30, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+				Targeting: model.Targeting{Countries: []string{"DE"}, Regions: []string{"BY"}}})
+			addServableLineItem(r, &model.LineItem{ID: "li_any", AdvertiserID: "adv_1", Bid: 10, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+
+			tt.q.Placement = "top"
+			tt.q.Limit = 1
+			assertServed(t, s, tt.q, tt.want)
+		})
+	}
+}
+
+func TestAdService_GetWinningAds_CreativeConstraints(t *testing.T) {
+	s, r := newTestAdService()
+	// the rotation always picks the first creative which can be selected
+	s.creativeService.randFloat = func() float64 { return 0 }
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	for _, creative := range []*model.Creative{
+		{ID: "cr_leaderboard", AdvertiserID: "adv_1", Format: model.CreativeFormatImage, Width: 728, Height: 90, Status: model.CreativeStatusApproved},
+		{ID: "cr_rectangle", AdvertiserID: "adv_1", Format: model.CreativeFormatImage, Width: 300, Height: 250, Status: model.CreativeStatusApproved},
+	} {
+		_ = r.creatives.CreateCreative(creative)
+	}
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_1", AdvertiserID: "adv_1", Bid: 1, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+		Creatives: []model.LineItemCreative{{CreativeID: "cr_leaderboard", Weight: 1}, {CreativeID: "cr_rectangle", Weight: 1}}})
+
+	tests := []struct {
+		name string
+		q    AdQuery
+		want string
+	}{
+		{"unconstrained", AdQuery{}, "cr_leaderboard"},
+		{"fitting size", AdQuery{Sizes: []string{"300x250"}}, "cr_rectangle"},
+		{"fitting format", AdQuery{Formats: []model.CreativeFormat{model.CreativeFormatImage}, Sizes: []string{"728x90"}}, "cr_leaderboard"},
+		{"no fitting creative", AdQuery{Formats: []model.CreativeFormat{model.CreativeFormatVideo}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.q.Placement = "top"
+			tt.q.Limit = 1
+			ads, err := s.GetWinningAds(tt.q)
+			if err != nil {
+				t.Fatalf("GetWinningAds() error = %v", err)
+			}
+			got := ""
+			if len(ads) > 0 {
+				got = ads[0].Creative.ID
+			}
+			if got != tt.want {
+				t.Errorf("GetWinningAds() served creative %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdService_GetWinningAds_Segments(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID string
+		want   string
+	}{
+		{"included segment", "user_sports", "li_sports"},
+		{"excluded segment", "user_customer", "li_any"},
+		{"expired membership", "user_expired", "li_prospecting"},
+		{"unknown user", "user_unknown", "li_prospecting"},
+		{"no user", "", "li_prospecting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, r := newTestAdService()
+			now := time.Now()
+			_ = r.segments.AddMemberships([]model.SegmentMembership{
+				{UserID: "user_sports", SegmentID: "sports", ExpiresAt: now.Add(time.Hour)},
+				{UserID: "user_customer", SegmentID: "customers", ExpiresAt: now.Add(time.Hour)},
+				{UserID: "user_expired", SegmentID: "sports", ExpiresAt: now.Add(-time.Minute)},
+				{UserID: "user_expired", SegmentID: "customers", ExpiresAt: now.Add(-time.Minute)},
+			})
+			_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+			addServableLineItem(r, &model.LineItem{ID: "li_sports", AdvertiserID: "adv_1", Bid: 30, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+				Targeting: model.Targeting{IncludeSegments: []string{"sports", "outdoor"}}})
+			addServableLineItem(r, &model.LineItem{ID: "li_prospecting", AdvertiserID: "adv_1", Bid: 20, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+				Targeting: model.Targeting{ExcludeSegments: []string{"customers"}}})
+			addServableLineItem(r, &model.LineItem{ID: "li_any", AdvertiserID: "adv_1", Bid: 10, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+
+			assertServed(t, s, AdQuery{Placement: "top", Limit: 1, UserID: tt.userID}, tt.want)
+		})
+	}
+}
+
+func TestAdService_GetWinningAds_Deals(t *testing.T) {
+	tests := []struct {
+		name string
+		q    AdQuery
+		want string
+	}{
+		{"open auction without deals", AdQuery{Placement: "top", Limit: 1}, "li_open"},
+		{"floor deal over open auction", AdQuery{Placement: "top", Limit: 1, DealIDs: []string{"deal_floor"}}, "li_floor"},
+		{"fixed deal over floor deal", AdQuery{Placement: "top", Limit: 1, DealIDs: []string{"deal_floor", "deal_fixed"}}, "li_fixed"},
+		{"unknown deal", AdQuery{Placement: "top", Limit: 1, DealIDs: []string{"deal_other"}}, "li_open"},
+		{"private auction without eligible deal", AdQuery{Placement: "top", Limit: 1, DealIDs: []string{"deal_other"}, PrivateAuction: true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, r := newTestAdService()
+			_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+			_ = r.deals.CreateDeal(&model.Deal{ID: "deal_fixed", PriceType: model.DealPriceTypeFixed, Price: 5})
+			_ = r.deals.CreateDeal(&model.Deal{ID: "deal_floor", PriceType: model.DealPriceTypeFloor, Price: 5})
+			addServableLineItem(r, &model.LineItem{ID: "li_open", AdvertiserID: "adv_1", Bid: 50, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+			addServableLineItem(r, &model.LineItem{ID: "li_floor", AdvertiserID: "adv_1", Bid: 10, Budget: 100, Placement: "top", Status: model.LineItemStatusActive, DealID: "deal_floor"})
+			addServableLineItem(r, &model.LineItem{ID: "li_fixed", AdvertiserID: "adv_1", Bid: 5, Budget: 100, Placement: "top", Status: model.LineItemStatusActive, DealID: "deal_fixed"})
+
+			ads, err := s.GetWinningAds(tt.q)
+			if err != nil {
+				t.Fatalf("GetWinningAds() error = %v", err)
+			}
+			var got, gotDeal string
+			if len(ads) > 0 {
+				got, gotDeal = ads[0].ID, ads[0].DealID
+			}
+			if got != tt.want {
+				t.Errorf("GetWinningAds() = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && tt.want != "li_open" && gotDeal == "" {
+				t.Errorf("GetWinningAds() deal ID is empty for %q", got)
+			}
+		})
+	}
+}
+
+func TestAdService_GetWinningAds_Priority(t *testing.T) {
+	s, r := newTestAdService()
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_house", AdvertiserID: "adv_1", Placement: "top", Status: model.LineItemStatusActive, Priority: model.LineItemPriorityHouse})
+	addServableLineItem(r, &model.LineItem{ID: "li_standard", AdvertiserID: "adv_1", Bid: 20, Budget: 20, Placement: "top", Status: model.LineItemStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_guaranteed", AdvertiserID: "adv_1", Bid: 5, Budget: 5, Placement: "top", Status: model.LineItemStatusActive,
+		Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 1})
+
+	ads, err := s.GetWinningAds(AdQuery{Placement: "top", Limit: 3})
+	if err != nil {
+		t.Fatalf("GetWinningAds() error = %v", err)
+	}
+	var got []string
+	for _, ad := range ads {
+		got = append(got, ad.ID)
+	}
+	if want := []string{"li_guaranteed", "li_standard", "li_house"}; !slices.Equal(got, want) {
+		t.Errorf("GetWinningAds() = %v, want %v", got, want)
+	}
+
+	// budgets are exhausted, house ads keep filling the placement for free
+	assertServed(t, s, AdQuery{Placement: "top", Limit: 1}, "li_house", "li_house")
+	house, _ := r.lineItems.GetLineItemById("li_house")
+	if house.Budget != 0 {
+		t.Errorf("house budget = %v, want 0", house.Budget)
+	}
+	assertServed(t, s, AdQuery{Placement: "top", Limit: 1, ExcludeHouse: true}, "")
+}
+
+func TestAdService_GetWinningAds_Pacing(t *testing.T) {
+	s, r := newTestAdService()
+	s.delivery.randFloat = func() float64 { return 0.99 }
+	now := time.Now()
+	start, end := now.Add(-5*time.Hour), now.Add(5*time.Hour)
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_ahead", AdvertiserID: "adv_1", Bid: 10, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+		Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 10, StartDate: &start, EndDate: &end})
+	addServableLineItem(r, &model.LineItem{ID: "li_behind", AdvertiserID: "adv_1", Bid: 1, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+		Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 10, StartDate: &start, EndDate: &end})
+	addServableLineItem(r, &model.LineItem{ID: "li_ended", AdvertiserID: "adv_1", Bid: 50, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+		EndDate: &start})
+	for range 8 {
+		_ = r.tracking.IncrementRollups(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: "li_ahead", Timestamp: now},
+			model.RollupGranularityHour)
+	}
+
+	// li_ahead delivered 8 of 5 expected impressions and sits out the request
+	assertServed(t, s, AdQuery{Placement: "top", Limit: 1}, "li_behind")
+	s.delivery.randFloat = func() float64 { return 0.5 }
+	assertServed(t, s, AdQuery{Placement: "top", Limit: 1}, "li_ahead")
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// AdvertiserService provides operations for advertisers
+type AdvertiserService struct {
+	repo repo.AdvertiserRepository
+	log  *zap.SugaredLogger
+}
+
+// NewAdvertiserService creates a new AdvertiserService
+func NewAdvertiserService(repo repo.AdvertiserRepository, log *zap.SugaredLogger) *AdvertiserService {
+	return &AdvertiserService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Create creates a new active advertiser
+func (s *AdvertiserService) Create(item model.AdvertiserCreate) (*model.Advertiser, error) {
+	now := time.Now()
+
+	advertiser := &model.Advertiser{
+		ID:              "adv_" + uuid.New().String(),
+		Name:            item.Name,
+		Status:          model.AdvertiserStatusActive,
+		DefaultCurrency: item.DefaultCurrency,
+		BudgetCap:       item.BudgetCap,
+		Domain:          item.Domain,
+		Category:        item.Category,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if err := s.repo.CreateAdvertiser(advertiser); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Advertiser created",
+		"id", advertiser.ID,
+		"name", advertiser.Name,
+		"domain", advertiser.Domain,
+	)
+	return advertiser, nil
+}
+
+// UpdateBlocklist replaces the blocklist of an advertiser
+func (s *AdvertiserService) UpdateBlocklist(id string, blocklist model.Blocklist) (*model.Advertiser, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	advertiser := *existing
+	advertiser.Blocklist = blocklist
+	advertiser.UpdatedAt = time.Now()
+	if err := s.repo.UpdateAdvertiser(&advertiser); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Advertiser blocklist updated",
+		"id", advertiser.ID,
+		"placements", len(blocklist.Placements),
+		"categories", len(blocklist.Categories),
+		"keywords", len(blocklist.Keywords),
+		"domains", len(blocklist.Domains),
+	)
+	return &advertiser, nil
+}
+
+// GetByID retrieves an advertiser by ID
+func (s *AdvertiserService) GetByID(id string) (*model.Advertiser, error) {
+	advertiser, err := s.repo.GetAdvertiserById(id)
+	if err != nil {
+		return nil, err
+	}
+	if advertiser == nil {
+		return nil, domain_errors.ErrAdvertiserNotFound
+	}
+	return advertiser, nil
+}
+
+// GetAll retrieves all advertisers, optionally filtered by status
+func (s *AdvertiserService) GetAll(status model.AdvertiserStatus) ([]*model.Advertiser, error) {
+	return s.repo.GetAdvertisers(repo.GetAdvertisersFilter{Status: status})
+}
+
+// Update applies a partial update to an advertiser
+func (s *AdvertiserService) Update(id string, update model.AdvertiserUpdate) (*model.Advertiser, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	advertiser := *existing
+	if update.Name != nil {
+		advertiser.Name = *update.Name
+	}
+	if update.Status != nil {
+		advertiser.Status = *update.Status
+	}
+	if update.BudgetCap != nil {
+		advertiser.BudgetCap = *update.BudgetCap
+	}
+	if update.Domain != nil {
+		advertiser.Domain = *update.Domain
+	}
+	if update.Category != nil {
+		advertiser.Category = *update.Category
+	}
+	advertiser.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateAdvertiser(&advertiser); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Advertiser updated",
+		"id", advertiser.ID,
+		"status", advertiser.Status,
+		"budget_cap", advertiser.BudgetCap,
+	)
+	return &advertiser, nil
+}
+
+// CheckActive returns an error unless the advertiser exists and is active
+func (s *AdvertiserService) CheckActive(id string) error {
+	advertiser, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if advertiser.Status != model.AdvertiserStatusActive {
+		return domain_errors.ErrAdvertiserSuspended
+	}
+	return nil
+}
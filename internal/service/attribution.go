@@ -0,0 +1,110 @@
+package service
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// AttributionWindows are the default conversion lookback windows,
+// used for line items which do not declare their own
+type AttributionWindows struct {
+	Click time.Duration
+	View  time.Duration
+}
+
+// AttributionService attributes conversions to the last click of the same
+// user, falling back to the last impression, within lookback windows
+type AttributionService struct {
+	trackingRepo repo.TrackingEventRepository
+	lineItemRepo repo.LineItemRepository
+	defaults     AttributionWindows
+	log          *zap.SugaredLogger
+}
+
+func NewAttributionService(trackingRepo repo.TrackingEventRepository, lineItemRepo repo.LineItemRepository, defaults AttributionWindows, log *zap.SugaredLogger) *AttributionService {
+	return &AttributionService{
+		trackingRepo: trackingRepo,
+		lineItemRepo: lineItemRepo,
+		defaults:     defaults,
+		log:          log,
+	}
+}
+
+// Attribute sets LineItemID, Attribution and AttributedEventID of a conversion.
+// Conversions without a qualifying touchpoint are marked as unattributed.
+func (s *AttributionService) Attribute(conversion *model.TrackingEvent) error {
+	conversion.LineItemID = ""
+	conversion.Attribution = model.AttributionTypeNone
+	conversion.AttributedEventID = ""
+
+	events, _, err := s.trackingRepo.GetTrackingEvents(repo.GetTrackingEventsFilter{
+		UserID: conversion.UserID,
+		To:     conversion.Timestamp.Add(time.Nanosecond),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Events are in arrival order, so the latest touchpoints are picked by timestamp
+	windows := make(map[string]AttributionWindows)
+	var lastClick, lastImpression *model.TrackingEvent
+	for _, event := range events {
+		if event.EventType == model.TrackingEventTypeConversion || !event.IsValid() {
+			continue
+		}
+		w, ok := windows[event.LineItemID]
+		if !ok {
+			if w, err = s.windows(event.LineItemID); err != nil {
+				return err
+			}
+			windows[event.LineItemID] = w
+		}
+		elapsed := conversion.Timestamp.Sub(event.Timestamp)
+		switch event.EventType {
+		case model.TrackingEventTypeClick:
+			if elapsed <= w.Click && (lastClick == nil || event.Timestamp.After(lastClick.Timestamp)) {
+				lastClick = event
+			}
+		case model.TrackingEventTypeImpression:
+			if elapsed <= w.View && (lastImpression == nil || event.Timestamp.After(lastImpression.Timestamp)) {
+				lastImpression = event
+			}
+		}
+	}
+
+	switch {
+	case lastClick != nil:
+		s.attribute(conversion, lastClick, model.AttributionTypeClick)
+	case lastImpression != nil:
+		s.attribute(conversion, lastImpression, model.AttributionTypeImpression)
+	}
+	return nil
+}
+
+func (s *AttributionService) attribute(conversion, touchpoint *model.TrackingEvent, attribution model.AttributionType) {
+	conversion.LineItemID = touchpoint.LineItemID
+	conversion.Attribution = attribution
+	conversion.AttributedEventID = touchpoint.ID
+	if conversion.Placement == "" {
+		conversion.Placement = touchpoint.Placement
+	}
+}
+
+func (s *AttributionService) windows(lineItemID string) (AttributionWindows, error) {
+	w := s.defaults
+	lineItem, err := s.lineItemRepo.GetLineItemById(lineItemID)
+	if err != nil || lineItem == nil {
+		return w, err
+	}
+	if lineItem.ClickLookbackHours > 0 {
+		w.Click = time.Duration(lineItem.ClickLookbackHours) * time.Hour
+	}
+	if lineItem.ViewLookbackHours > 0 {
+		w.View = time.Duration(lineItem.ViewLookbackHours) * time.Hour
+	}
+	return w, nil
+}
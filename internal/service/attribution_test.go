@@ -0,0 +1,99 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+func TestAttributionService_Attribute(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	lineItemRepo := repo.NewLineItemRepository(log)
+	_ = lineItemRepo.CreateLineItem(&model.LineItem{ID: "li_short", ClickLookbackHours: 1, ViewLookbackHours: 1})
+	_ = lineItemRepo.CreateLineItem(&model.LineItem{ID: "li_default"})
+
+	conversionTime := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	touchpoint := func(id, lineItemID string, eventType model.TrackingEventType, ago time.Duration) *model.TrackingEvent {
+		return &model.TrackingEvent{
+			ID:         id,
+			EventType:  eventType,
+			LineItemID: lineItemID,
+			UserID:     "u_1",
+			Timestamp:  conversionTime.Add(-ago),
+		}
+	}
+
+	tests := []struct {
+		name            string
+		events          []*model.TrackingEvent
+		wantLineItem    string
+		wantAttribution model.AttributionType
+		wantEvent       string
+	}{
+		{
+			name: "last click wins over later impression",
+			events: []*model.TrackingEvent{
+				touchpoint("ev_1", "li_default", model.TrackingEventTypeClick, 48*time.Hour),
+				touchpoint("ev_2", "li_default", model.TrackingEventTypeClick, 3*time.Hour),
+				touchpoint("ev_3", "li_short", model.TrackingEventTypeImpression, time.Minute),
+			},
+			wantLineItem:    "li_default",
+			wantAttribution: model.AttributionTypeClick,
+			wantEvent:       "ev_2",
+		},
+		{
+			name: "click outside line item lookback falls back to impression",
+			events: []*model.TrackingEvent{
+				touchpoint("ev_1", "li_default", model.TrackingEventTypeImpression, 5*time.Hour),
+				touchpoint("ev_2", "li_short", model.TrackingEventTypeClick, 2*time.Hour),
+			},
+			wantLineItem:    "li_default",
+			wantAttribution: model.AttributionTypeImpression,
+			wantEvent:       "ev_1",
+		},
+		{
+			name: "touchpoints outside every window are not attributed",
+			events: []*model.TrackingEvent{
+				touchpoint("ev_1", "li_default", model.TrackingEventTypeImpression, 25*time.Hour),
+				touchpoint("ev_2", "li_default", model.TrackingEventTypeClick, 8*24*time.Hour),
+			},
+			wantAttribution: model.AttributionTypeNone,
+		},
+		{
+			name: "touchpoints after the conversion are ignored",
+			events: []*model.TrackingEvent{
+				touchpoint("ev_1", "li_default", model.TrackingEventTypeClick, -time.Minute),
+			},
+			wantAttribution: model.AttributionTypeNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trackingRepo := repo.NewTrackingEventRepository(log)
+			for _, event := range tt.events {
+				_ = trackingRepo.CreateTrackingEvent(event)
+			}
+			s := NewAttributionService(trackingRepo, lineItemRepo, AttributionWindows{Click: 7 * 24 * time.Hour, View: 24 * time.Hour}, log)
+
+			conversion := &model.TrackingEvent{
+				EventType:  model.TrackingEventTypeConversion,
+				LineItemID: "li_client_supplied",
+				UserID:     "u_1",
+				Timestamp:  conversionTime,
+				OrderValue: 42,
+			}
+			if err := s.Attribute(conversion); err != nil {
+				t.Fatalf("Attribute() error = %v", err)
+			}
+			if conversion.LineItemID != tt.wantLineItem || conversion.Attribution != tt.wantAttribution || conversion.AttributedEventID != tt.wantEvent {
+				t.Errorf("Attribute() = (%q, %q, %q), want (%q, %q, %q)",
+					conversion.LineItemID, conversion.Attribution, conversion.AttributedEventID,
+					tt.wantLineItem, tt.wantAttribution, tt.wantEvent)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// cpm converts per impression prices of line items to CPM prices of auctions
+const cpm = 1000
+
+// AuctionBid is a bid for an impression of an external auction with the ad it offers
+type AuctionBid struct {
+	*model.Bid
+	Ad               *model.Ad
+	AdvertiserDomain string
+}
+
+// AuctionService bids into external auctions. The bid price is reserved from
+// the budgets when bidding, separately from their spend. The reservation is
+// committed as spend at the clearing price on a confirmed win, and released on
+// loss or when the bid expires pending.
+type AuctionService struct {
+	ads         *AdService
+	repo        repo.BidRepository
+	impressions ImpressionRecorder
+	now         func() time.Time
+	log         *zap.SugaredLogger
+}
+
+// ImpressionRecorder records the impressions of won external auctions, whose
+// ads carry no impression tracker, at the price charged for them
+type ImpressionRecorder interface {
+	RecordImpression(event *model.TrackingEvent) error
+}
+
+// NewAuctionService creates a new AuctionService, impressions may be nil when
+// won auctions are not recorded as impressions
+func NewAuctionService(ads *AdService, repo repo.BidRepository, impressions ImpressionRecorder, log *zap.SugaredLogger) *AuctionService {
+	return &AuctionService{
+		ads:         ads,
+		repo:        repo,
+		impressions: impressions,
+		now:         time.Now,
+		log:         log,
+	}
+}
+
+// Bid returns up to q.Limit bids at or above the CPM floor for an impression of
+// an external auction, reserving their price from the budgets
+func (s *AuctionService) Bid(requestID, impID string, floorCPM float64, q AdQuery) ([]*AuctionBid, error) {
+	q.BidFloor = floorCPM / cpm
+	q.ExcludeHouse = true
+	ads, err := s.ads.selectAds(q, s.ads.reserveBudgets)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	bids := make([]*AuctionBid, 0, len(ads))
+	for _, ad := range ads {
+		bid := &model.Bid{
+			ID:           "bid_" + uuid.New().String(),
+			RequestID:    requestID,
+			ImpID:        impID,
+			LineItemID:   ad.ID,
+			AdvertiserID: ad.AdvertiserID,
+			DealID:       ad.DealID,
+			Placement:    q.Placement,
+			Price:        ad.Bid * cpm,
+			Status:       model.BidStatusPending,
+			CreatedAt:    now,
+		}
+		var advertiserDomain string
+		if lineItem, err := s.ads.lineItemRepo.GetLineItemById(ad.ID); err == nil && lineItem != nil {
+			bid.CampaignID = lineItem.CampaignID
+		}
+		if advertiser, err := s.ads.advertiserRepo.GetAdvertiserById(ad.AdvertiserID); err == nil && advertiser != nil {
+			advertiserDomain = advertiser.Domain
+		}
+		if ad.Creative != nil {
+			bid.CreativeID = ad.Creative.ID
+		}
+		if err := s.repo.CreateBid(bid); err != nil {
+			s.release(bid, bid.Price)
+			return nil, err
+		}
+		bids = append(bids, &AuctionBid{Bid: bid, Ad: ad, AdvertiserDomain: advertiserDomain})
+	}
+	return bids, nil
+}
+
+// Win confirms the win of a pending bid, committing the clearing price and
+// releasing the rest of the reservation. Repeated notices return the won bid,
+// notices of lost bids fail with ErrBidStatusConflict. A nil price is the bid price.
+func (s *AuctionService) Win(bidID string, price *float64) (*model.Bid, error) {
+	bid, err := s.GetByID(bidID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	won, err := s.repo.UpdateStatus(bidID, []model.BidStatus{model.BidStatusPending}, func(b *model.Bid) {
+		b.Status = model.BidStatusWon
+		b.ClearingPrice = clearingPrice(bid, price)
+		b.WonAt = &now
+	})
+	if errors.Is(err, domain_errors.ErrBidStatusConflict) && won.Status != model.BidStatusLost {
+		return won, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.commit(won)
+	s.recordImpression(won)
+	s.log.Infow("auction won",
+		"bid_id", won.ID,
+		"line_item_id", won.LineItemID,
+		"clearing_price", won.ClearingPrice,
+	)
+	return won, nil
+}
+
+// Bill records the billing notice of a bid, confirming its win first when no
+// win notice was received. Repeated notices return the billed bid. A nil price
+// is the bid price.
+func (s *AuctionService) Bill(bidID string, price *float64) (*model.Bid, error) {
+	if _, err := s.Win(bidID, price); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	billed, err := s.repo.UpdateStatus(bidID, []model.BidStatus{model.BidStatusWon}, func(b *model.Bid) {
+		b.Status = model.BidStatusBilled
+		b.BilledAt = &now
+	})
+	if errors.Is(err, domain_errors.ErrBidStatusConflict) && billed.Status == model.BidStatusBilled {
+		return billed, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("auction billed",
+		"bid_id", billed.ID,
+		"line_item_id", billed.LineItemID,
+		"clearing_price", billed.ClearingPrice,
+	)
+	return billed, nil
+}
+
+// Loss records the loss of a pending bid and releases its reservation.
+// Repeated notices return the lost bid, notices of won bids fail with
+// ErrBidStatusConflict.
+func (s *AuctionService) Loss(bidID string, reason int) (*model.Bid, error) {
+	now := s.now()
+	lost, err := s.repo.UpdateStatus(bidID, []model.BidStatus{model.BidStatusPending}, func(b *model.Bid) {
+		b.Status = model.BidStatusLost
+		b.LossReason = reason
+		b.LostAt = &now
+	})
+	if errors.Is(err, domain_errors.ErrBidStatusConflict) && lost.Status == model.BidStatusLost {
+		return lost, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.release(lost, lost.Price)
+	s.log.Infow("auction lost",
+		"bid_id", lost.ID,
+		"line_item_id", lost.LineItemID,
+		"loss_reason", lost.LossReason,
+	)
+	return lost, nil
+}
+
+// commit spends the clearing price of a won bid and releases its reservation
+func (s *AuctionService) commit(bid *model.Bid) {
+	if err := s.ads.adjustBudgets(bid.LineItemID, bid.ClearingPrice/cpm, -bid.Price/cpm); err != nil {
+		s.log.Errorw("error in committing bid reservation",
+			"bid_id", bid.ID,
+			"line_item_id", bid.LineItemID,
+			"error", err)
+	}
+}
+
+// recordImpression records the impression of a won bid, charged at its clearing price
+func (s *AuctionService) recordImpression(bid *model.Bid) {
+	if s.impressions == nil {
+		return
+	}
+	err := s.impressions.RecordImpression(&model.TrackingEvent{
+		EventType:  model.TrackingEventTypeImpression,
+		LineItemID: bid.LineItemID,
+		CreativeID: bid.CreativeID,
+		Placement:  bid.Placement,
+		Timestamp:  *bid.WonAt,
+		Metadata:   map[string]string{"bid_id": bid.ID},
+		Cost:       bid.ClearingPrice / cpm,
+	})
+	if err != nil {
+		s.log.Errorw("error in recording auction impression",
+			"bid_id", bid.ID,
+			"line_item_id", bid.LineItemID,
+			"error", err)
+	}
+}
+
+// release returns the CPM amount of a bid reservation to the budgets
+func (s *AuctionService) release(bid *model.Bid, amount float64) {
+	if amount <= 0 {
+		return
+	}
+	if err := s.ads.adjustBudgets(bid.LineItemID, 0, -amount/cpm); err != nil {
+		s.log.Errorw("error in releasing bid reservation",
+			"bid_id", bid.ID,
+			"line_item_id", bid.LineItemID,
+			"error", err)
+	}
+}
+
+// GetByID retrieves a bid by ID
+func (s *AuctionService) GetByID(id string) (*model.Bid, error) {
+	bid, err := s.repo.GetBidById(id)
+	if err != nil {
+		return nil, err
+	}
+	if bid == nil {
+		return nil, domain_errors.ErrBidNotFound
+	}
+	return bid, nil
+}
+
+// RunExpiry deletes expired bids every interval until ctx is done. Bids still
+// pending after bidTTL expire and their reservations are released, so win
+// notices must arrive within bidTTL. Won bids are already charged and are kept
+// for billingTTL for their billing notice, as are lost and billed bids for
+// repeated notices. Notices of deleted bids are rejected as unknown.
+func (s *AuctionService) RunExpiry(ctx context.Context, interval, bidTTL, billingTTL time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expire(bidTTL, billingTTL)
+		}
+	}
+}
+
+func (s *AuctionService) expire(bidTTL, billingTTL time.Duration) {
+	now := s.now()
+	pending, err := s.repo.DeleteBefore(now.Add(-bidTTL), []model.BidStatus{model.BidStatusPending})
+	if err != nil {
+		s.log.Errorw("error in deleting expired bids", "error", err)
+		return
+	}
+	for _, bid := range pending {
+		s.release(bid, bid.Price)
+	}
+	settled, err := s.repo.DeleteBefore(now.Add(-billingTTL), []model.BidStatus{model.BidStatusWon, model.BidStatusBilled, model.BidStatusLost})
+	if err != nil {
+		s.log.Errorw("error in deleting settled bids", "error", err)
+		return
+	}
+	if len(pending) > 0 || len(settled) > 0 {
+		s.log.Infow("Expired bids deleted", "pending", len(pending), "settled", len(settled))
+	}
+}
+
+// clearingPrice returns the reported auction price, which never exceeds the bid
+func clearingPrice(bid *model.Bid, price *float64) float64 {
+	if price == nil || *price < 0 || *price > bid.Price {
+		return bid.Price
+	}
+	return *price
+}
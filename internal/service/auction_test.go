@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+func TestAuctionService_BidAndBill(t *testing.T) {
+	s, r := newTestAdService()
+	auction := NewAuctionService(s, repo.NewBidRepository(zap.NewNop().Sugar()), nil, zap.NewNop().Sugar())
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Domain: "brand.example", Status: model.AdvertiserStatusActive})
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_blocked", Domain: "blocked.example", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_blocked", AdvertiserID: "adv_blocked", Bid: 0.005, Budget: 1, Placement: "top", Status: model.LineItemStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_1", AdvertiserID: "adv_1", Bid: 0.002, Budget: 1, Placement: "top", Status: model.LineItemStatusActive})
+
+	q := AdQuery{Placement: "top", Limit: 1, BlockedAdvertiserDomains: []string{"BLOCKED.example"}}
+	if bids, err := auction.Bid("req_1", "1", 2.5, q); err != nil || len(bids) != 0 {
+		t.Fatalf("Bid() under floor = %v, %v, want no bids", bids, err)
+	}
+	bids, err := auction.Bid("req_2", "1", 1.5, q)
+	if err != nil || len(bids) != 1 {
+		t.Fatalf("Bid() = %v, %v, want one bid", bids, err)
+	}
+	bid := bids[0]
+	if bid.LineItemID != "li_1" || bid.Price != 2 || bid.AdvertiserDomain != "brand.example" {
+		t.Errorf("Bid() = %+v, want li_1 at CPM 2 of brand.example", bid.Bid)
+	}
+	lineItem, _ := r.lineItems.GetLineItemById("li_1")
+	advertiser, _ := r.advertisers.GetAdvertiserById("adv_1")
+	if !budgetEqual(lineItem.Budget, 1) || !budgetEqual(lineItem.Reserved, 0.002) || !budgetEqual(advertiser.Reserved, 0.002) || advertiser.Spent != 0 {
+		t.Errorf("line item budget = %v reserved = %v, advertiser spent = %v reserved = %v, want bid price reserved but not spent",
+			lineItem.Budget, lineItem.Reserved, advertiser.Spent, advertiser.Reserved)
+	}
+
+	price := 1.2
+	if won, err := auction.Win(bid.ID, &price); err != nil || won.Status != model.BidStatusWon {
+		t.Fatalf("Win() = %v, %v, want won bid", won, err)
+	}
+	for i := 0; i < 2; i++ {
+		billed, err := auction.Bill(bid.ID, &price)
+		if err != nil || billed.Status != model.BidStatusBilled || billed.ClearingPrice != 1.2 {
+			t.Fatalf("Bill() = %v, %v, want billed at 1.2", billed, err)
+		}
+	}
+	lineItem, _ = r.lineItems.GetLineItemById("li_1")
+	advertiser, _ = r.advertisers.GetAdvertiserById("adv_1")
+	if !budgetEqual(lineItem.Budget, 1-0.0012) || !budgetEqual(advertiser.Spent, 0.0012) || !budgetEqual(lineItem.Reserved, 0) || !budgetEqual(advertiser.Reserved, 0) {
+		t.Errorf("budget = %v, advertiser spent = %v, want one impression charged at clearing price and nothing reserved", lineItem.Budget, advertiser.Spent)
+	}
+
+	overpriced := 5.0
+	bids, _ = auction.Bid("req_3", "1", 0, q)
+	if billed, err := auction.Bill(bids[0].ID, &overpriced); err != nil || billed.ClearingPrice != 2 {
+		t.Errorf("Bill() above bid = %v, %v, want clearing price capped at bid", billed, err)
+	}
+}
+
+func TestAuctionService_LossAndExpiry(t *testing.T) {
+	s, r := newTestAdService()
+	auction := NewAuctionService(s, repo.NewBidRepository(zap.NewNop().Sugar()), nil, zap.NewNop().Sugar())
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_1", AdvertiserID: "adv_1", Bid: 0.002, Budget: 1, Placement: "top", Status: model.LineItemStatusActive})
+	q := AdQuery{Placement: "top", Limit: 1}
+	budget := func() float64 {
+		lineItem, _ := r.lineItems.GetLineItemById("li_1")
+		return lineItem.Budget - lineItem.Reserved
+	}
+
+	bids, _ := auction.Bid("req_1", "1", 0, q)
+	for i := 0; i < 2; i++ {
+		lost, err := auction.Loss(bids[0].ID, 102)
+		if err != nil || lost.Status != model.BidStatusLost || lost.LossReason != 102 {
+			t.Fatalf("Loss() = %v, %v, want lost bid", lost, err)
+		}
+	}
+	if !budgetEqual(budget(), 1) {
+		t.Errorf("budget after loss = %v, want reservation released", budget())
+	}
+	if _, err := auction.Win(bids[0].ID, nil); !errors.Is(err, domain_errors.ErrBidStatusConflict) {
+		t.Errorf("Win() after loss error = %v, want %v", err, domain_errors.ErrBidStatusConflict)
+	}
+
+	bids, _ = auction.Bid("req_2", "1", 0, q)
+	if _, err := auction.Win(bids[0].ID, nil); err != nil {
+		t.Fatalf("Win() error = %v", err)
+	}
+	if _, err := auction.Loss(bids[0].ID, 0); !errors.Is(err, domain_errors.ErrBidStatusConflict) {
+		t.Errorf("Loss() after win error = %v, want %v", err, domain_errors.ErrBidStatusConflict)
+	}
+
+	pending, _ := auction.Bid("req_3", "1", 0, q)
+	auction.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	auction.expire(time.Hour, 24*time.Hour)
+	if !budgetEqual(budget(), 1-0.002) {
+		t.Errorf("budget after expiry = %v, want only the won bid charged", budget())
+	}
+	if _, err := auction.Win(pending[0].ID, nil); !errors.Is(err, domain_errors.ErrBidNotFound) {
+		t.Errorf("Win() of expired pending bid error = %v, want %v", err, domain_errors.ErrBidNotFound)
+	}
+	// won bids are kept for their billing notice
+	if billed, err := auction.Bill(bids[0].ID, nil); err != nil || billed.Status != model.BidStatusBilled {
+		t.Errorf("Bill() of won bid after bid TTL = %v, %v, want billed bid", billed, err)
+	}
+	if !budgetEqual(budget(), 1-0.002) {
+		t.Errorf("budget after late billing = %v, want the won bid charged once", budget())
+	}
+
+	auction.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	auction.expire(time.Hour, 24*time.Hour)
+	if _, err := auction.Bill(bids[0].ID, nil); !errors.Is(err, domain_errors.ErrBidNotFound) {
+		t.Errorf("Bill() after billing TTL error = %v, want %v", err, domain_errors.ErrBidNotFound)
+	}
+}
+
+// budgetEqual compares budgets which went through reservations and releases
+func budgetEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// audienceIDPrefix marks segment IDs which are retargeting audiences
+const audienceIDPrefix = "aud_"
+
+// AudienceService maintains retargeting audiences, whose members are stored in
+// the segment store under the audience ID
+type AudienceService struct {
+	repo              repo.AudienceRepository
+	trackingRepo      repo.TrackingEventRepository
+	lineItemRepo      repo.LineItemRepository
+	advertiserService *AdvertiserService
+	segments          *SegmentService
+	now               func() time.Time
+	log               *zap.SugaredLogger
+}
+
+// NewAudienceService creates a new AudienceService
+func NewAudienceService(repo repo.AudienceRepository, trackingRepo repo.TrackingEventRepository, lineItemRepo repo.LineItemRepository, advertiserService *AdvertiserService, segments *SegmentService, log *zap.SugaredLogger) *AudienceService {
+	return &AudienceService{
+		repo:              repo,
+		trackingRepo:      trackingRepo,
+		lineItemRepo:      lineItemRepo,
+		advertiserService: advertiserService,
+		segments:          segments,
+		now:               time.Now,
+		log:               log,
+	}
+}
+
+// Create creates a new audience for an existing, active advertiser and fills it
+// from the tracking events within its lookback
+func (s *AudienceService) Create(item model.AudienceCreate) (*model.Audience, error) {
+	if err := s.advertiserService.CheckActive(item.AdvertiserID); err != nil {
+		return nil, err
+	}
+	for _, id := range item.Rule.LineItemIDs {
+		lineItem, err := s.lineItemRepo.GetLineItemById(id)
+		if err != nil {
+			return nil, err
+		}
+		if lineItem == nil {
+			return nil, domain_errors.ErrLineItemNotFound
+		}
+		if lineItem.AdvertiserID != item.AdvertiserID {
+			return nil, domain_errors.ErrLineItemAdvertiserMismatch
+		}
+	}
+	now := s.now()
+
+	audience := &model.Audience{
+		ID:           audienceIDPrefix + uuid.New().String(),
+		Name:         item.Name,
+		AdvertiserID: item.AdvertiserID,
+		Rule:         item.Rule,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := s.repo.CreateAudience(audience); err != nil {
+		return nil, err
+	}
+	members, err := s.backfill(audience)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("Audience created",
+		"id", audience.ID,
+		"name", audience.Name,
+		"advertiser_id", audience.AdvertiserID,
+		"members", members,
+	)
+	return audience, nil
+}
+
+// GetByID retrieves an audience by ID
+func (s *AudienceService) GetByID(id string) (*model.Audience, error) {
+	audience, err := s.repo.GetAudienceById(id)
+	if err != nil {
+		return nil, err
+	}
+	if audience == nil {
+		return nil, domain_errors.ErrAudienceNotFound
+	}
+	return audience, nil
+}
+
+// GetAll retrieves all audiences, optionally filtered by advertiser ID
+func (s *AudienceService) GetAll(advertiserID string) ([]*model.Audience, error) {
+	return s.repo.GetAudiences(repo.GetAudiencesFilter{AdvertiserID: advertiserID})
+}
+
+// CheckTargeting returns an error unless every audience among the targeted
+// segments exists and belongs to the advertiser. Other segments are not checked.
+func (s *AudienceService) CheckTargeting(targeting model.Targeting, advertiserID string) error {
+	for _, id := range slices.Concat(targeting.IncludeSegments, targeting.ExcludeSegments) {
+		if !strings.HasPrefix(id, audienceIDPrefix) {
+			continue
+		}
+		audience, err := s.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if audience.AdvertiserID != advertiserID {
+			return domain_errors.ErrAudienceAdvertiserMismatch
+		}
+	}
+	return nil
+}
+
+// OnTrackingEvent adds the user of the event to the audiences of the line item's
+// advertiser whose rule it matches, and removes converters from audiences excluding
+// them. Conversions which are not attributed to a line item cannot be traced to an
+// advertiser, so they remove the user from the audiences of every advertiser.
+func (s *AudienceService) OnTrackingEvent(event *model.TrackingEvent) {
+	if event.UserID == "" {
+		return
+	}
+	if event.EventType == model.TrackingEventTypeConversion {
+		s.removeConverter(event)
+		return
+	}
+	if event.LineItemID == "" {
+		return
+	}
+	lineItem, err := s.lineItemRepo.GetLineItemById(event.LineItemID)
+	if err != nil || lineItem == nil {
+		return
+	}
+	audiences, err := s.repo.GetAudiences(repo.GetAudiencesFilter{AdvertiserID: lineItem.AdvertiserID})
+	if err != nil {
+		s.log.Warnw("audiences not updated", "line_item_id", event.LineItemID, "error", err)
+		return
+	}
+	for _, audience := range audiences {
+		if _, err := s.apply(audience, event, lineItem); err != nil {
+			s.log.Warnw("audience not updated", "audience_id", audience.ID, "error", err)
+		}
+	}
+}
+
+// removeConverter removes the user of the conversion from the audiences excluding
+// converters of the advertiser of the attributed line item, or of every advertiser
+// when the conversion is not attributed
+func (s *AudienceService) removeConverter(conversion *model.TrackingEvent) {
+	var filter repo.GetAudiencesFilter
+	if conversion.LineItemID != "" {
+		lineItem, err := s.lineItemRepo.GetLineItemById(conversion.LineItemID)
+		if err != nil || lineItem == nil {
+			return
+		}
+		filter.AdvertiserID = lineItem.AdvertiserID
+	}
+	audiences, err := s.repo.GetAudiences(filter)
+	if err != nil {
+		s.log.Warnw("converter not removed from audiences", "line_item_id", conversion.LineItemID, "error", err)
+		return
+	}
+	for _, audience := range audiences {
+		if !audience.Rule.ExcludeConverters {
+			continue
+		}
+		if err := s.segments.RemoveMembership(conversion.UserID, audience.ID); err != nil {
+			s.log.Warnw("converter not removed from audience", "audience_id", audience.ID, "error", err)
+		}
+	}
+}
+
+// backfill applies the stored events within the lookback of the audience and
+// returns the number of events which added a member
+func (s *AudienceService) backfill(audience *model.Audience) (int, error) {
+	events, _, err := s.trackingRepo.GetTrackingEvents(repo.GetTrackingEventsFilter{
+		EventType: audience.Rule.EventType,
+		From:      s.now().Add(-audience.Rule.Lookback()),
+	})
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, event := range events {
+		if event.UserID == "" || !event.IsValid() {
+			continue
+		}
+		lineItem, err := s.lineItemRepo.GetLineItemById(event.LineItemID)
+		if err != nil || lineItem == nil {
+			continue
+		}
+		ok, err := s.apply(audience, event, lineItem)
+		if err != nil {
+			return added, err
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// apply adds the user of the event to the audience until the lookback after the
+// event when the event matches the audience rule, returning whether it was added
+func (s *AudienceService) apply(audience *model.Audience, event *model.TrackingEvent, lineItem *model.LineItem) (bool, error) {
+	rule := audience.Rule
+	if event.EventType != rule.EventType || lineItem.AdvertiserID != audience.AdvertiserID {
+		return false, nil
+	}
+	if len(rule.LineItemIDs) > 0 && !slices.Contains(rule.LineItemIDs, lineItem.ID) {
+		return false, nil
+	}
+	expiresAt := event.Timestamp.Add(rule.Lookback())
+	if !expiresAt.After(s.now()) {
+		return false, nil
+	}
+	if rule.ExcludeConverters {
+		converted, err := s.hasConverted(event.UserID, audience)
+		if err != nil || converted {
+			return false, err
+		}
+	}
+	err := s.segments.AddMemberships([]model.SegmentMembership{{
+		UserID:    event.UserID,
+		SegmentID: audience.ID,
+		ExpiresAt: expiresAt,
+	}})
+	return err == nil, err
+}
+
+// hasConverted reports whether the user has a valid conversion attributed to a
+// line item of the audience's advertiser, or not attributed at all, within the
+// lookback of the audience
+func (s *AudienceService) hasConverted(userID string, audience *model.Audience) (bool, error) {
+	conversions, _, err := s.trackingRepo.GetTrackingEvents(repo.GetTrackingEventsFilter{
+		UserID:    userID,
+		EventType: model.TrackingEventTypeConversion,
+		From:      s.now().Add(-audience.Rule.Lookback()),
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, conversion := range conversions {
+		if !conversion.IsValid() {
+			continue
+		}
+		if conversion.LineItemID == "" {
+			return true, nil
+		}
+		lineItem, err := s.lineItemRepo.GetLineItemById(conversion.LineItemID)
+		if err == nil && lineItem != nil && lineItem.AdvertiserID == audience.AdvertiserID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+func TestAudienceService_Materialize(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	advertiserRepo := repo.NewAdvertiserRepository(log)
+	lineItemRepo := repo.NewLineItemRepository(log)
+	trackingRepo := repo.NewTrackingEventRepository(log)
+	_ = advertiserRepo.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	_ = lineItemRepo.CreateLineItem(&model.LineItem{ID: "li_1", AdvertiserID: "adv_1"})
+	_ = lineItemRepo.CreateLineItem(&model.LineItem{ID: "li_other", AdvertiserID: "adv_2"})
+
+	segmentService := NewSegmentService(repo.NewSegmentRepository(log), time.Hour, log)
+	segmentService.now = func() time.Time { return now }
+	s := NewAudienceService(repo.NewAudienceRepository(log), trackingRepo, lineItemRepo, NewAdvertiserService(advertiserRepo, log), segmentService, log)
+	s.now = func() time.Time { return now }
+	tracking := NewTrackingService(trackingRepo, nil, nil, nil, nil, log)
+	tracking.Subscribe(s)
+
+	event := func(userID, lineItemID string, eventType model.TrackingEventType, ago time.Duration) *model.TrackingEvent {
+		return &model.TrackingEvent{EventType: eventType, LineItemID: lineItemID, UserID: userID, Timestamp: now.Add(-ago)}
+	}
+	// clicks before the audience exists are backfilled
+	_ = tracking.Track(event("u_backfilled", "li_1", model.TrackingEventTypeClick, 48*time.Hour))
+	_ = tracking.Track(event("u_stale", "li_1", model.TrackingEventTypeClick, 8*24*time.Hour))
+
+	audience, err := s.Create(model.AudienceCreate{
+		Name:         "Clicked, not converted",
+		AdvertiserID: "adv_1",
+		Rule:         model.AudienceRule{EventType: model.TrackingEventTypeClick, LookbackHours: 7 * 24, ExcludeConverters: true},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	_ = tracking.Track(event("u_clicked", "li_1", model.TrackingEventTypeClick, time.Hour))
+	_ = tracking.Track(event("u_viewed", "li_1", model.TrackingEventTypeImpression, time.Hour))
+	_ = tracking.Track(event("u_other", "li_other", model.TrackingEventTypeClick, time.Hour))
+	_ = tracking.Track(event("u_converted", "li_1", model.TrackingEventTypeClick, 2*time.Hour))
+	// the attributed conversion removes the member and blocks later clicks
+	_ = tracking.Track(event("u_converted", "li_1", model.TrackingEventTypeConversion, time.Hour))
+	_ = tracking.Track(event("u_converted", "li_1", model.TrackingEventTypeClick, time.Minute))
+	// so does a conversion which is not attributed to any line item
+	_ = tracking.Track(event("u_unattributed", "li_1", model.TrackingEventTypeClick, 2*time.Hour))
+	_ = tracking.Track(event("u_unattributed", "", model.TrackingEventTypeConversion, time.Hour))
+	_ = tracking.Track(event("u_unattributed", "li_1", model.TrackingEventTypeClick, time.Minute))
+
+	tests := []struct {
+		userID string
+		want   bool
+	}{
+		{"u_backfilled", true},
+		{"u_stale", false},
+		{"u_clicked", true},
+		{"u_viewed", false},
+		{"u_other", false},
+		{"u_converted", false},
+		{"u_unattributed", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			segments, err := segmentService.GetUserSegments(tt.userID)
+			if err != nil {
+				t.Fatalf("GetUserSegments() error = %v", err)
+			}
+			if got := slices.Contains(segments, audience.ID); got != tt.want {
+				t.Errorf("user in audience = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudienceService_CheckTargeting(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	audienceRepo := repo.NewAudienceRepository(log)
+	_ = audienceRepo.CreateAudience(&model.Audience{ID: "aud_1", AdvertiserID: "adv_1"})
+	s := NewAudienceService(audienceRepo, nil, nil, nil, nil, log)
+
+	tests := []struct {
+		name         string
+		targeting    model.Targeting
+		advertiserID string
+		want         error
+	}{
+		{"own audience", model.Targeting{IncludeSegments: []string{"aud_1", "sports"}}, "adv_1", nil},
+		{"audience of another advertiser", model.Targeting{IncludeSegments: []string{"aud_1"}}, "adv_2", domain_errors.ErrAudienceAdvertiserMismatch},
+		{"unknown audience", model.Targeting{ExcludeSegments: []string{"aud_2"}}, "adv_1", domain_errors.ErrAudienceNotFound},
+		{"uploaded segments are not checked", model.Targeting{IncludeSegments: []string{"sports"}}, "adv_2", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.CheckTargeting(tt.targeting, tt.advertiserID); !errors.Is(err, tt.want) {
+				t.Errorf("CheckTargeting() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
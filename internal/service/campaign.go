@@ -0,0 +1,127 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// CampaignService provides operations for campaigns
+type CampaignService struct {
+	repo              repo.CampaignRepository
+	advertiserService *AdvertiserService
+	log               *zap.SugaredLogger
+}
+
+// NewCampaignService creates a new CampaignService
+func NewCampaignService(repo repo.CampaignRepository, advertiserService *AdvertiserService, log *zap.SugaredLogger) *CampaignService {
+	return &CampaignService{
+		repo:              repo,
+		advertiserService: advertiserService,
+		log:               log,
+	}
+}
+
+// Create creates a new active campaign for an existing, active advertiser
+func (s *CampaignService) Create(item model.CampaignCreate) (*model.Campaign, error) {
+	if err := s.advertiserService.CheckActive(item.AdvertiserID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	campaign := &model.Campaign{
+		ID:           "cmp_" + uuid.New().String(),
+		Name:         item.Name,
+		AdvertiserID: item.AdvertiserID,
+		Budget:       item.Budget,
+		StartDate:    item.StartDate,
+		EndDate:      item.EndDate,
+		Status:       model.CampaignStatusActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := s.repo.CreateCampaign(campaign); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Campaign created",
+		"id", campaign.ID,
+		"name", campaign.Name,
+		"advertiser_id", campaign.AdvertiserID,
+	)
+	return campaign, nil
+}
+
+// GetByID retrieves a campaign by ID
+func (s *CampaignService) GetByID(id string) (*model.Campaign, error) {
+	campaign, err := s.repo.GetCampaignById(id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, domain_errors.ErrCampaignNotFound
+	}
+	return campaign, nil
+}
+
+// GetAll retrieves all campaigns, optionally filtered by advertiser ID and status
+func (s *CampaignService) GetAll(advertiserID string, status model.CampaignStatus) ([]*model.Campaign, error) {
+	return s.repo.GetCampaigns(repo.GetCampaignsFilter{AdvertiserID: advertiserID, Status: status})
+}
+
+// Update applies a partial update to a campaign. Pausing a campaign stops
+// serving of all its line items.
+func (s *CampaignService) Update(id string, update model.CampaignUpdate) (*model.Campaign, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign := *existing
+	if update.Name != nil {
+		campaign.Name = *update.Name
+	}
+	if update.Budget != nil {
+		campaign.Budget = *update.Budget
+	}
+	if update.StartDate != nil {
+		campaign.StartDate = *update.StartDate
+	}
+	if update.EndDate != nil {
+		campaign.EndDate = *update.EndDate
+	}
+	if update.Status != nil {
+		campaign.Status = *update.Status
+	}
+	if !campaign.EndDate.After(campaign.StartDate) {
+		return nil, domain_errors.ErrCampaignInvalidFlight
+	}
+	campaign.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateCampaign(&campaign); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Campaign updated",
+		"id", campaign.ID,
+		"status", campaign.Status,
+		"budget", campaign.Budget,
+	)
+	return &campaign, nil
+}
+
+// CheckOwnership returns an error unless the campaign exists and belongs to the advertiser
+func (s *CampaignService) CheckOwnership(id, advertiserID string) error {
+	campaign, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if campaign.AdvertiserID != advertiserID {
+		return domain_errors.ErrCampaignAdvertiserMismatch
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// Optimized rotation parameters
+const (
+	// rotationExploreRate is the share of selections which ignore performance
+	rotationExploreRate = 0.1
+	// Prior of the smoothed CTR, equivalent to 1 click in 100 impressions, so
+	// creatives without delivery are neither starved nor preferred
+	ctrPriorClicks      = 1.0
+	ctrPriorImpressions = 100.0
+)
+
+// CreativePolicy contains the rules of automatic creative checks
+type CreativePolicy struct {
+	// BlockedDomains are landing page domains which are rejected, including their subdomains
+	BlockedDomains []string
+	// PlacementSizes maps placements to their allowed "<width>x<height>" sizes.
+	// Placements which are not listed allow any size.
+	PlacementSizes map[string][]string
+	// VideoPlacements only allow video creatives, which are not allowed on other placements
+	VideoPlacements []string
+}
+
+// CreativeService provides operations for creatives, reviews them and selects creatives of line items
+type CreativeService struct {
+	repo              repo.CreativeRepository
+	advertiserService *AdvertiserService
+	policy            CreativePolicy
+	randFloat         func() float64
+	log               *zap.SugaredLogger
+}
+
+// NewCreativeService creates a new CreativeService
+func NewCreativeService(repo repo.CreativeRepository, advertiserService *AdvertiserService, policy CreativePolicy, log *zap.SugaredLogger) *CreativeService {
+	return &CreativeService{
+		repo:              repo,
+		advertiserService: advertiserService,
+		policy:            policy,
+		randFloat:         rand.Float64,
+		log:               log,
+	}
+}
+
+// Create creates a new creative for an existing, active advertiser.
+// Creatives start in review, unless automatic checks reject them.
+func (s *CreativeService) Create(item model.CreativeCreate) (*model.Creative, error) {
+	if err := s.advertiserService.CheckActive(item.AdvertiserID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	creative := &model.Creative{
+		ID:           "cr_" + uuid.New().String(),
+		AdvertiserID: item.AdvertiserID,
+		Name:         item.Name,
+		Format:       item.Format,
+		Width:        item.Width,
+		Height:       item.Height,
+		ImageURL:     item.ImageURL,
+		HTML:         item.HTML,
+		Native:       item.Native,
+		Video:        item.Video,
+		LandingURL:   item.LandingURL,
+		Status:       model.CreativeStatusPendingReview,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if violations := s.policyViolations(creative); len(violations) > 0 {
+		creative.Status = model.CreativeStatusRejected
+		creative.RejectionReasons = violations
+		creative.ReviewedAt = &now
+	}
+
+	if err := s.repo.CreateCreative(creative); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Creative created",
+		"id", creative.ID,
+		"advertiser_id", creative.AdvertiserID,
+		"format", creative.Format,
+		"status", creative.Status,
+	)
+	return creative, nil
+}
+
+// GetByID retrieves a creative by ID
+func (s *CreativeService) GetByID(id string) (*model.Creative, error) {
+	creative, err := s.repo.GetCreativeById(id)
+	if err != nil {
+		return nil, err
+	}
+	if creative == nil {
+		return nil, domain_errors.ErrCreativeNotFound
+	}
+	return creative, nil
+}
+
+// GetAll retrieves all creatives, optionally filtered by advertiser ID and status
+func (s *CreativeService) GetAll(advertiserID string, status model.CreativeStatus) ([]*model.Creative, error) {
+	return s.repo.GetCreatives(repo.GetCreativesFilter{AdvertiserID: advertiserID, Status: status})
+}
+
+// CheckAttachable returns an error unless the creative exists, belongs to the
+// advertiser and its size is allowed on the placement
+func (s *CreativeService) CheckAttachable(id, advertiserID, placement string) error {
+	creative, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if creative.AdvertiserID != advertiserID {
+		return domain_errors.ErrCreativeAdvertiserMismatch
+	}
+	return s.CheckPlacement(creative, placement)
+}
+
+// Approve approves a creative for serving, after repeating the automatic checks
+func (s *CreativeService) Approve(id string) (*model.Creative, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if violations := s.policyViolations(existing); len(violations) > 0 {
+		return nil, fmt.Errorf("%w: %s", domain_errors.ErrCreativePolicyViolation, strings.Join(violations, "; "))
+	}
+	return s.review(existing, model.CreativeStatusApproved, nil)
+}
+
+// Reject rejects a creative, which stops it from serving
+func (s *CreativeService) Reject(id string, reason string) (*model.Creative, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.review(existing, model.CreativeStatusRejected, []string{reason})
+}
+
+func (s *CreativeService) review(existing *model.Creative, status model.CreativeStatus, reasons []string) (*model.Creative, error) {
+	now := time.Now()
+	creative := *existing
+	creative.Status = status
+	creative.RejectionReasons = reasons
+	creative.ReviewedAt = &now
+	creative.UpdatedAt = now
+
+	if err := s.repo.UpdateCreative(&creative); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Creative reviewed",
+		"id", creative.ID,
+		"status", creative.Status,
+		"reasons", creative.RejectionReasons,
+	)
+	return &creative, nil
+}
+
+// policyViolations runs the automatic checks which do not depend on placement
+func (s *CreativeService) policyViolations(creative *model.Creative) []string {
+	var violations []string
+	landing, err := url.Parse(creative.LandingURL)
+	if err != nil || (landing.Scheme != "http" && landing.Scheme != "https") || landing.Hostname() == "" {
+		return append(violations, "landing_url must be an absolute http or https URL")
+	}
+	host := strings.ToLower(landing.Hostname())
+	for _, blocked := range s.policy.BlockedDomains {
+		blocked = strings.ToLower(strings.TrimSpace(blocked))
+		if blocked != "" && (host == blocked || strings.HasSuffix(host, "."+blocked)) {
+			violations = append(violations, "landing_url domain "+host+" is blocked")
+		}
+	}
+	return violations
+}
+
+// CheckPlacement returns an error unless the creative's format and size are allowed
+// on the placement. Native creatives adapt to the placement, so any size is allowed.
+func (s *CreativeService) CheckPlacement(creative *model.Creative, placement string) error {
+	if video := slices.Contains(s.policy.VideoPlacements, placement); video != (creative.Format == model.CreativeFormatVideo) {
+		return fmt.Errorf("%w: %s on %s", domain_errors.ErrCreativeFormatMismatch, creative.Format, placement)
+	}
+	sizes, ok := s.policy.PlacementSizes[placement]
+	if !ok || creative.Format == model.CreativeFormatNative {
+		return nil
+	}
+	if !slices.Contains(sizes, creative.Size()) {
+		return fmt.Errorf("%w: %s on %s", domain_errors.ErrCreativeSizeMismatch, creative.Size(), placement)
+	}
+	return nil
+}
+
+// HasApproved reports whether the line item has at least one approved creative in rotation
+func (s *CreativeService) HasApproved(lineItem *model.LineItem) bool {
+	for _, attached := range lineItem.Creatives {
+		if attached.Weight <= 0 {
+			continue
+		}
+		creative, err := s.repo.GetCreativeById(attached.CreativeID)
+		if err == nil && creative != nil && creative.Status == model.CreativeStatusApproved {
+			return true
+		}
+	}
+	return false
+}
+
+type creativeCandidate struct {
+	creative *model.Creative
+	weight   float64
+}
+
+// Select picks one of the approved creatives of a line item which fit the
+// allowed formats and sizes according to its rotation, or returns nil when the
+// line item has no servable creative. Empty formats or sizes allow any.
+func (s *CreativeService) Select(lineItem *model.LineItem, formats []model.CreativeFormat, sizes []string) (*model.Creative, error) {
+	candidates := make([]creativeCandidate, 0, len(lineItem.Creatives))
+	for _, attached := range lineItem.Creatives {
+		if attached.Weight <= 0 {
+			continue
+		}
+		creative, err := s.repo.GetCreativeById(attached.CreativeID)
+		if err != nil {
+			return nil, err
+		}
+		if creative == nil || creative.Status != model.CreativeStatusApproved || !creativeFits(creative, formats, sizes) {
+			continue
+		}
+		candidates = append(candidates, creativeCandidate{creative: creative, weight: float64(attached.Weight)})
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	if lineItem.CreativeRotation == model.CreativeRotationOptimized && s.randFloat() >= rotationExploreRate {
+		return s.bestPerforming(candidates)
+	}
+	return s.weighted(candidates), nil
+}
+
+// creativeFits reports whether the creative has one of the formats and, unless
+// it is native, one of the "<width>x<height>" sizes
+func creativeFits(creative *model.Creative, formats []model.CreativeFormat, sizes []string) bool {
+	if len(formats) > 0 && !slices.Contains(formats, creative.Format) {
+		return false
+	}
+	return len(sizes) == 0 || creative.Format == model.CreativeFormatNative || slices.Contains(sizes, creative.Size())
+}
+
+func (s *CreativeService) weighted(candidates []creativeCandidate) *model.Creative {
+	total := 0.0
+	for _, c := range candidates {
+		total += c.weight
+	}
+	r := s.randFloat() * total
+	for _, c := range candidates {
+		if r < c.weight {
+			return c.creative
+		}
+		r -= c.weight
+	}
+	return candidates[len(candidates)-1].creative
+}
+
+func (s *CreativeService) bestPerforming(candidates []creativeCandidate) (*model.Creative, error) {
+	var (
+		best    *model.Creative
+		bestCTR float64
+	)
+	for _, c := range candidates {
+		stats, err := s.repo.GetStats(c.creative.ID)
+		if err != nil {
+			return nil, err
+		}
+		ctr := (float64(stats.Clicks) + ctrPriorClicks) / (float64(stats.Impressions) + ctrPriorImpressions)
+		if best == nil || ctr > bestCTR {
+			best, bestCTR = c.creative, ctr
+		}
+	}
+	return best, nil
+}
+
+// OnTrackingEvent counts the events of creatives, of which impressions and
+// clicks are used for optimized rotation
+func (s *CreativeService) OnTrackingEvent(event *model.TrackingEvent) {
+	if event.CreativeID == "" {
+		return
+	}
+	if err := s.repo.IncrementStats(event.CreativeID, event.EventType); err != nil {
+		s.log.Warnw("creative stats not updated",
+			"creative_id", event.CreativeID,
+			"error", err)
+	}
+}
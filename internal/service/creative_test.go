@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+func TestCreativeService_Select(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	creativeRepo := repo.NewCreativeRepository(log)
+	for _, c := range []*model.Creative{
+		{ID: "cr_a", Status: model.CreativeStatusApproved},
+		{ID: "cr_b", Status: model.CreativeStatusApproved},
+		{ID: "cr_rejected", Status: model.CreativeStatusRejected},
+	} {
+		_ = creativeRepo.CreateCreative(c)
+	}
+	// cr_b has the better CTR
+	for i := 0; i < 1000; i++ {
+		_ = creativeRepo.IncrementStats("cr_a", model.TrackingEventTypeImpression)
+		_ = creativeRepo.IncrementStats("cr_b", model.TrackingEventTypeImpression)
+	}
+	for i := 0; i < 50; i++ {
+		_ = creativeRepo.IncrementStats("cr_b", model.TrackingEventTypeClick)
+	}
+	attached := []model.LineItemCreative{
+		{CreativeID: "cr_a", Weight: 3},
+		{CreativeID: "cr_b", Weight: 1},
+		{CreativeID: "cr_rejected", Weight: 10},
+	}
+
+	tests := []struct {
+		name     string
+		rotation model.CreativeRotation
+		creative []model.LineItemCreative
+		rand     float64
+		want     string
+	}{
+		{"weighted picks first creative in its share", model.CreativeRotationWeighted, attached, 0.7, "cr_a"},
+		{"weighted picks second creative in its share", model.CreativeRotationWeighted, attached, 0.8, "cr_b"},
+		{"optimized exploits best ctr", model.CreativeRotationOptimized, attached, 0.5, "cr_b"},
+		{"optimized explores by weight", model.CreativeRotationOptimized, attached, 0.05, "cr_a"},
+		{"rejected creatives are never served", model.CreativeRotationWeighted, attached[2:], 0.5, ""},
+		{"line item without creatives", model.CreativeRotationWeighted, nil, 0.5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewCreativeService(creativeRepo, nil, CreativePolicy{}, log)
+			s.randFloat = func() float64 { return tt.rand }
+
+			got, err := s.Select(&model.LineItem{Creatives: tt.creative, CreativeRotation: tt.rotation}, nil, nil)
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			gotID := ""
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.want {
+				t.Errorf("Select() = %q, want %q", gotID, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreativeService_Review(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	advertiserRepo := repo.NewAdvertiserRepository(log)
+	_ = advertiserRepo.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	s := NewCreativeService(repo.NewCreativeRepository(log), NewAdvertiserService(advertiserRepo, log), CreativePolicy{
+		BlockedDomains:  []string{"blocked.example"},
+		PlacementSizes:  map[string][]string{"top": {"728x90"}},
+		VideoPlacements: []string{"preroll"},
+	}, log)
+
+	tests := []struct {
+		name       string
+		landingURL string
+		wantStatus model.CreativeStatus
+		approveErr error
+	}{
+		{"valid landing url waits for review", "https://shop.example/sale", model.CreativeStatusPendingReview, nil},
+		{"blocked domain is rejected", "https://blocked.example", model.CreativeStatusRejected, domain_errors.ErrCreativePolicyViolation},
+		{"blocked subdomain is rejected", "http://www.Blocked.example/x", model.CreativeStatusRejected, domain_errors.ErrCreativePolicyViolation},
+		{"relative landing url is rejected", "/sale", model.CreativeStatusRejected, domain_errors.ErrCreativePolicyViolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creative, err := s.Create(model.CreativeCreate{
+				AdvertiserID: "adv_1",
+				Format:       model.CreativeFormatImage,
+				Width:        728,
+				Height:       90,
+				LandingURL:   tt.landingURL,
+			})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if creative.Status != tt.wantStatus {
+				t.Errorf("Create() status = %q, want %q", creative.Status, tt.wantStatus)
+			}
+			if (creative.Status == model.CreativeStatusRejected) != (len(creative.RejectionReasons) > 0) {
+				t.Errorf("Create() rejection reasons = %v", creative.RejectionReasons)
+			}
+
+			approved, err := s.Approve(creative.ID)
+			if !errors.Is(err, tt.approveErr) {
+				t.Fatalf("Approve() error = %v, want %v", err, tt.approveErr)
+			}
+			if err == nil && (approved.Status != model.CreativeStatusApproved || approved.ReviewedAt == nil) {
+				t.Errorf("Approve() = %+v", approved)
+			}
+		})
+	}
+
+	creative, _ := s.Create(model.CreativeCreate{AdvertiserID: "adv_1", Format: model.CreativeFormatImage, Width: 300, Height: 250, LandingURL: "https://shop.example"})
+	if err := s.CheckPlacement(creative, "top"); !errors.Is(err, domain_errors.ErrCreativeSizeMismatch) {
+		t.Errorf("CheckPlacement(top) error = %v, want %v", err, domain_errors.ErrCreativeSizeMismatch)
+	}
+	if err := s.CheckPlacement(creative, "sidebar"); err != nil {
+		t.Errorf("CheckPlacement(sidebar) error = %v, want nil", err)
+	}
+	if err := s.CheckPlacement(creative, "preroll"); !errors.Is(err, domain_errors.ErrCreativeFormatMismatch) {
+		t.Errorf("CheckPlacement(preroll) error = %v, want %v", err, domain_errors.ErrCreativeFormatMismatch)
+	}
+	video := &model.Creative{Format: model.CreativeFormatVideo, Width: 1280, Height: 720}
+	if err := s.CheckPlacement(video, "preroll"); err != nil {
+		t.Errorf("CheckPlacement(video, preroll) error = %v, want nil", err)
+	}
+	if err := s.CheckPlacement(video, "sidebar"); !errors.Is(err, domain_errors.ErrCreativeFormatMismatch) {
+		t.Errorf("CheckPlacement(video, sidebar) error = %v, want %v", err, domain_errors.ErrCreativeFormatMismatch)
+	}
+	rejected, err := s.Reject(creative.ID, "misleading claims")
+	if err != nil || rejected.Status != model.CreativeStatusRejected || len(rejected.RejectionReasons) != 1 {
+		t.Errorf("Reject() = %+v, %v", rejected, err)
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// DealService provides operations for private marketplace deals and ranks
+// their line items over the open auction
+type DealService struct {
+	repo repo.DealRepository
+	// tiers are the deal price types in priority order, line items of deals
+	// whose price type is not listed rank with the open auction
+	tiers []model.DealPriceType
+	log   *zap.SugaredLogger
+}
+
+// NewDealService creates a new DealService
+func NewDealService(repo repo.DealRepository, tiers []model.DealPriceType, log *zap.SugaredLogger) *DealService {
+	return &DealService{
+		repo:  repo,
+		tiers: tiers,
+		log:   log,
+	}
+}
+
+// Create creates a new deal, keeping the given deal ID
+func (s *DealService) Create(item model.DealCreate) (*model.Deal, error) {
+	now := time.Now()
+	deal := &model.Deal{
+		ID:            item.ID,
+		Name:          item.Name,
+		PriceType:     item.PriceType,
+		Price:         item.Price,
+		AdvertiserIDs: item.AdvertiserIDs,
+		Placements:    item.Placements,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if deal.ID == "" {
+		deal.ID = "deal_" + uuid.New().String()
+	}
+
+	if err := s.repo.CreateDeal(deal); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Deal created",
+		"id", deal.ID,
+		"name", deal.Name,
+		"price_type", deal.PriceType,
+		"price", deal.Price,
+	)
+	return deal, nil
+}
+
+// GetByID retrieves a deal by ID
+func (s *DealService) GetByID(id string) (*model.Deal, error) {
+	deal, err := s.repo.GetDealById(id)
+	if err != nil {
+		return nil, err
+	}
+	if deal == nil {
+		return nil, domain_errors.ErrDealNotFound
+	}
+	return deal, nil
+}
+
+// GetAll retrieves all deals
+func (s *DealService) GetAll() ([]*model.Deal, error) {
+	return s.repo.GetDeals()
+}
+
+// DealBid returns the bid of a line item joining a deal. Line items of fixed
+// price deals bid the deal price, and line items of floor price deals must bid
+// at least the deal price.
+func (s *DealService) DealBid(dealID, advertiserID, placement string, bid float64) (float64, error) {
+	deal, err := s.GetByID(dealID)
+	if err != nil {
+		return 0, err
+	}
+	if !deal.Allows(advertiserID, placement) {
+		return 0, domain_errors.ErrDealNotAllowed
+	}
+	switch deal.PriceType {
+	case model.DealPriceTypeFixed:
+		return deal.Price, nil
+	case model.DealPriceTypeFloor:
+		if bid < deal.Price {
+			return 0, domain_errors.ErrDealBidBelowFloor
+		}
+	}
+	return bid, nil
+}
+
+// tier returns the priority tier of a line item, lower tiers fill first. Line
+// items outside deals form the last tier, the open auction. Without a
+// DealService every line item is in the open auction.
+func (s *DealService) tier(lineItem *model.LineItem) int {
+	if s == nil {
+		return 0
+	}
+	openAuction := len(s.tiers)
+	if lineItem.DealID == "" {
+		return openAuction
+	}
+	deal, err := s.repo.GetDealById(lineItem.DealID)
+	if err != nil || deal == nil {
+		return openAuction
+	}
+	if i := slices.Index(s.tiers, deal.PriceType); i >= 0 {
+		return i
+	}
+	return openAuction
+}
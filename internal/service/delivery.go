@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// PacingRules control the delivery pacing of guaranteed line items and when
+// they are forecast to underdeliver
+type PacingRules struct {
+	// MaxBoost caps the ranking boost of line items behind schedule
+	MaxBoost float64
+	// UnderdeliveryTolerance is the share of the goal a forecast may miss
+	// before the line item is underdelivering
+	UnderdeliveryTolerance float64
+	// ForecastWarmup is the part of the flight delivered before forecasting
+	ForecastWarmup time.Duration
+}
+
+// UnderdeliveryNotifier is notified of line items forecast to underdeliver
+type UnderdeliveryNotifier interface {
+	NotifyUnderdelivery(ctx context.Context, delivery *model.LineItemDelivery) error
+}
+
+// DeliveryService tracks the delivery of guaranteed line items towards their
+// impression goals, paces them evenly over their flight and alerts when they
+// are forecast to underdeliver
+type DeliveryService struct {
+	lineItemRepo repo.LineItemRepository
+	trackingRepo repo.TrackingEventRepository
+	rules        PacingRules
+	notifier     UnderdeliveryNotifier
+	now          func() time.Time
+	randFloat    func() float64
+	log          *zap.SugaredLogger
+
+	mu sync.Mutex
+	// alerted are the line items already notified as underdelivering
+	alerted map[string]bool
+
+	deliveredMu sync.RWMutex
+	// delivered are the impressions of guaranteed line items within their
+	// flight, which pace ad requests without reading the rollups. They are
+	// counted from the rollups when first needed and on every underdelivery
+	// check, and counted up by tracking events in between.
+	delivered map[string]int64
+}
+
+// NewDeliveryService creates a new DeliveryService, notifier may be nil when
+// underdelivery is only logged
+func NewDeliveryService(lineItemRepo repo.LineItemRepository, trackingRepo repo.TrackingEventRepository, rules PacingRules, notifier UnderdeliveryNotifier, log *zap.SugaredLogger) *DeliveryService {
+	return &DeliveryService{
+		lineItemRepo: lineItemRepo,
+		trackingRepo: trackingRepo,
+		rules:        rules,
+		notifier:     notifier,
+		now:          time.Now,
+		randFloat:    rand.Float64,
+		log:          log,
+		alerted:      make(map[string]bool),
+		delivered:    make(map[string]int64),
+	}
+}
+
+// GetByLineItemID returns the delivery of a line item, whose goal is zero
+// unless the line item is guaranteed
+func (s *DeliveryService) GetByLineItemID(id string) (*model.LineItemDelivery, error) {
+	lineItem, err := s.lineItemRepo.GetLineItemById(id)
+	if err != nil {
+		return nil, err
+	}
+	if lineItem == nil {
+		return nil, domain_errors.ErrLineItemNotFound
+	}
+	impressions, err := s.countImpressions(lineItem)
+	if err != nil {
+		return nil, err
+	}
+	return s.delivery(lineItem, impressions, s.now()), nil
+}
+
+// GetGuaranteed returns the delivery of every guaranteed line item, least
+// delivered first, and recounts their delivered impressions used for pacing
+func (s *DeliveryService) GetGuaranteed() ([]*model.LineItemDelivery, error) {
+	lineItems, err := s.lineItemRepo.GetLineItems(repo.GetLineItemsFilter{Priority: model.LineItemPriorityGuaranteed})
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	delivered := make(map[string]int64, len(lineItems))
+	result := make([]*model.LineItemDelivery, 0, len(lineItems))
+	for _, lineItem := range lineItems {
+		impressions, err := s.countImpressions(lineItem)
+		if err != nil {
+			return nil, err
+		}
+		delivered[lineItem.ID] = impressions
+		result = append(result, s.delivery(lineItem, impressions, now))
+	}
+	s.deliveredMu.Lock()
+	s.delivered = delivered
+	s.deliveredMu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Progress != result[j].Progress {
+			return result[i].Progress < result[j].Progress
+		}
+		return result[i].LineItemID < result[j].LineItemID
+	})
+	return result, nil
+}
+
+// CheckUnderdelivery notifies about guaranteed line items forecast to
+// underdeliver. A line item is notified once until it is back on track, and
+// again on the next check when the notification fails.
+func (s *DeliveryService) CheckUnderdelivery(ctx context.Context) error {
+	deliveries, err := s.GetGuaranteed()
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		s.mu.Lock()
+		alerted := s.alerted[delivery.LineItemID]
+		if !delivery.Underdelivering {
+			delete(s.alerted, delivery.LineItemID)
+		}
+		s.mu.Unlock()
+		if !delivery.Underdelivering || alerted {
+			continue
+		}
+
+		s.log.Warnw("Line item forecast to underdeliver",
+			"id", delivery.LineItemID,
+			"impression_goal", delivery.ImpressionGoal,
+			"impressions", delivery.Impressions,
+			"forecast", delivery.Forecast,
+		)
+		if s.notifier != nil {
+			if err := s.notifier.NotifyUnderdelivery(ctx, delivery); err != nil {
+				s.log.Errorw("error in notifying underdelivery",
+					"id", delivery.LineItemID,
+					"error", err)
+				continue
+			}
+		}
+		s.mu.Lock()
+		s.alerted[delivery.LineItemID] = true
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// OnTrackingEvent counts up the delivered impressions of guaranteed line items
+func (s *DeliveryService) OnTrackingEvent(event *model.TrackingEvent) {
+	if event.EventType != model.TrackingEventTypeImpression {
+		return
+	}
+	lineItem, err := s.lineItemRepo.GetLineItemById(event.LineItemID)
+	if err != nil || lineItem == nil || lineItem.Priority != model.LineItemPriorityGuaranteed || !lineItem.InFlight(event.Timestamp) {
+		return
+	}
+	s.deliveredMu.Lock()
+	defer s.deliveredMu.Unlock()
+	// line items which are not counted yet are counted from the rollups when needed
+	if _, ok := s.delivered[event.LineItemID]; ok {
+		s.delivered[event.LineItemID]++
+	}
+}
+
+// RunAlerts recounts delivered impressions and checks for underdelivering line
+// items every interval until ctx is done
+func (s *DeliveryService) RunAlerts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CheckUnderdelivery(ctx); err != nil {
+				s.log.Errorw("error in checking underdelivery", "error", err)
+			}
+		}
+	}
+}
+
+// pacing returns whether a guaranteed line item takes part in an ad request
+// and the boost of its score. Line items ahead of schedule take part with the
+// probability of their expected to delivered ratio, line items behind schedule
+// always take part and are boosted by the same ratio, up to MaxBoost. Line
+// items which reached their goal stop.
+func (s *DeliveryService) pacing(lineItem *model.LineItem, now time.Time) (bool, float64) {
+	impressions, err := s.impressions(lineItem)
+	if err != nil {
+		s.log.Errorw("error in pacing line item",
+			"id", lineItem.ID,
+			"error", err)
+		return true, 1
+	}
+	delivery := s.delivery(lineItem, impressions, now)
+	if delivery.ImpressionGoal > 0 && delivery.Remaining == 0 {
+		return false, 1
+	}
+	if delivery.Expected == 0 {
+		return true, 1
+	}
+	if delivery.Pace >= 1 {
+		return s.randFloat() < 1/delivery.Pace, 1
+	}
+	maxBoost := max(s.rules.MaxBoost, 1)
+	if delivery.Pace == 0 {
+		return true, maxBoost
+	}
+	return true, min(1/delivery.Pace, maxBoost)
+}
+
+// impressions returns the delivered impressions of a guaranteed line item,
+// counting them from the rollups when they are not counted yet
+func (s *DeliveryService) impressions(lineItem *model.LineItem) (int64, error) {
+	s.deliveredMu.RLock()
+	impressions, ok := s.delivered[lineItem.ID]
+	s.deliveredMu.RUnlock()
+	if ok {
+		return impressions, nil
+	}
+	impressions, err := s.countImpressions(lineItem)
+	if err != nil {
+		return 0, err
+	}
+	s.deliveredMu.Lock()
+	if counted, ok := s.delivered[lineItem.ID]; ok {
+		impressions = counted
+	} else {
+		s.delivered[lineItem.ID] = impressions
+	}
+	s.deliveredMu.Unlock()
+	return impressions, nil
+}
+
+// countImpressions counts the valid impressions of the line item within its
+// flight, to the minute. Hours wholly within the flight are counted from the
+// hourly rollups, and the partial hours at its start and end from the minute
+// rollups, as hourly rollups also count impressions outside the flight.
+func (s *DeliveryService) countImpressions(lineItem *model.LineItem) (int64, error) {
+	var from, to time.Time
+	if lineItem.StartDate != nil {
+		from = *lineItem.StartDate
+	}
+	if lineItem.EndDate != nil {
+		to = *lineItem.EndDate
+	}
+	hoursFrom, hoursTo := from, to
+	if !from.IsZero() {
+		if hoursFrom = model.RollupGranularityHour.Truncate(from); hoursFrom.Before(from) {
+			hoursFrom = hoursFrom.Add(time.Hour)
+		}
+	}
+	if !to.IsZero() {
+		hoursTo = model.RollupGranularityHour.Truncate(to)
+	}
+	// flights within a single hour have no whole hours
+	if !from.IsZero() && !to.IsZero() && !hoursFrom.Before(hoursTo) {
+		return s.countRollups(lineItem.ID, model.RollupGranularityMinute, from, to)
+	}
+
+	impressions, err := s.countRollups(lineItem.ID, model.RollupGranularityHour, hoursFrom, hoursTo)
+	if err != nil {
+		return 0, err
+	}
+	if !from.Equal(hoursFrom) {
+		first, err := s.countRollups(lineItem.ID, model.RollupGranularityMinute, from, hoursFrom)
+		if err != nil {
+			return 0, err
+		}
+		impressions += first
+	}
+	if !to.Equal(hoursTo) {
+		last, err := s.countRollups(lineItem.ID, model.RollupGranularityMinute, hoursTo, to)
+		if err != nil {
+			return 0, err
+		}
+		impressions += last
+	}
+	return impressions, nil
+}
+
+// countRollups counts the impressions of the line item in the rollups of the
+// granularity between from and to
+func (s *DeliveryService) countRollups(lineItemID string, granularity model.RollupGranularity, from, to time.Time) (int64, error) {
+	rollups, err := s.trackingRepo.GetRollups(repo.GetRollupsFilter{
+		LineItemID:  lineItemID,
+		Granularity: granularity,
+		From:        from,
+		To:          to,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var impressions int64
+	for _, rollup := range rollups {
+		impressions += rollup.Impressions
+	}
+	return impressions, nil
+}
+
+// delivery paces and forecasts line items with a goal and a flight at now
+// from their delivered impressions
+func (s *DeliveryService) delivery(lineItem *model.LineItem, impressions int64, now time.Time) *model.LineItemDelivery {
+	delivery := &model.LineItemDelivery{
+		LineItemID:     lineItem.ID,
+		Priority:       lineItem.Priority,
+		ImpressionGoal: lineItem.ImpressionGoal,
+		StartDate:      lineItem.StartDate,
+		EndDate:        lineItem.EndDate,
+		Impressions:    impressions,
+	}
+	if delivery.ImpressionGoal <= 0 {
+		return delivery
+	}
+	delivery.Remaining = max(delivery.ImpressionGoal-delivery.Impressions, 0)
+	delivery.Progress = min(float64(delivery.Impressions)/float64(delivery.ImpressionGoal), 1)
+	if lineItem.StartDate == nil || lineItem.EndDate == nil {
+		return delivery
+	}
+
+	flight := lineItem.EndDate.Sub(*lineItem.StartDate)
+	elapsed := min(max(now.Sub(*lineItem.StartDate), 0), flight)
+	if flight <= 0 || elapsed <= 0 {
+		return delivery
+	}
+	delivery.Expected = int64(float64(delivery.ImpressionGoal) * elapsed.Seconds() / flight.Seconds())
+	if delivery.Expected > 0 {
+		delivery.Pace = float64(delivery.Impressions) / float64(delivery.Expected)
+	}
+	if elapsed >= s.rules.ForecastWarmup || elapsed == flight {
+		delivery.Forecast = int64(float64(delivery.Impressions) * flight.Seconds() / elapsed.Seconds())
+		delivery.Underdelivering = float64(delivery.Forecast) < float64(delivery.ImpressionGoal)*(1-s.rules.UnderdeliveryTolerance)
+	}
+	return delivery
+}
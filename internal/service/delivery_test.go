@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+func TestDeliveryService_GetGuaranteed(t *testing.T) {
+	r := newTestRepos()
+	s := NewDeliveryService(r.lineItems, r.tracking, PacingRules{}, nil, zap.NewNop().Sugar())
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_behind", Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 10})
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_done", Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 2})
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_standard", Priority: model.LineItemPriorityStandard})
+	now := time.Now()
+	for _, id := range []string{"li_behind", "li_done", "li_done", "li_done", "li_standard"} {
+		_ = r.tracking.IncrementRollups(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: id, Timestamp: now},
+			model.RollupGranularityMinute, model.RollupGranularityHour)
+	}
+	_ = r.tracking.IncrementRollups(&model.TrackingEvent{EventType: model.TrackingEventTypeClick, LineItemID: "li_behind", Timestamp: now},
+		model.RollupGranularityHour)
+
+	deliveries, err := s.GetGuaranteed()
+	if err != nil {
+		t.Fatalf("GetGuaranteed() error = %v", err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("GetGuaranteed() = %d deliveries, want 2", len(deliveries))
+	}
+	behind, done := deliveries[0], deliveries[1]
+	if behind.LineItemID != "li_behind" || behind.Impressions != 1 || behind.Remaining != 9 || behind.Progress != 0.1 {
+		t.Errorf("GetGuaranteed()[0] = %+v, want li_behind with 1 of 10 impressions", behind)
+	}
+	if done.LineItemID != "li_done" || done.Impressions != 3 || done.Remaining != 0 || done.Progress != 1 {
+		t.Errorf("GetGuaranteed()[1] = %+v, want li_done over delivered", done)
+	}
+
+	standard, err := s.GetByLineItemID("li_standard")
+	if err != nil || standard.Impressions != 1 || standard.Progress != 0 {
+		t.Errorf("GetByLineItemID() = %+v, %v, want impressions without goal", standard, err)
+	}
+}
+
+func TestDeliveryService_pacing(t *testing.T) {
+	r := newTestRepos()
+	s := NewDeliveryService(r.lineItems, r.tracking, PacingRules{MaxBoost: 4}, nil, zap.NewNop().Sugar())
+	now := time.Now()
+	start, end := now.Add(-5*time.Hour), now.Add(5*time.Hour)
+	notStarted := now.Add(time.Hour)
+
+	tests := []struct {
+		name            string
+		lineItem        *model.LineItem
+		impressions     int
+		rand            float64
+		wantParticipate bool
+		wantBoost       float64
+	}{
+		{"on schedule", &model.LineItem{ID: "li_on", ImpressionGoal: 100, StartDate: &start, EndDate: &end}, 50, 0.99, true, 1},
+		{"behind schedule is boosted", &model.LineItem{ID: "li_behind", ImpressionGoal: 100, StartDate: &start, EndDate: &end}, 25, 0.99, true, 2},
+		{"boost is capped", &model.LineItem{ID: "li_idle", ImpressionGoal: 100, StartDate: &start, EndDate: &end}, 0, 0.99, true, 4},
+		{"ahead of schedule is throttled", &model.LineItem{ID: "li_ahead", ImpressionGoal: 100, StartDate: &start, EndDate: &end}, 80, 0.7, false, 1},
+		{"ahead of schedule takes part by chance", &model.LineItem{ID: "li_lucky", ImpressionGoal: 100, StartDate: &start, EndDate: &end}, 80, 0.6, true, 1},
+		{"goal reached", &model.LineItem{ID: "li_done", ImpressionGoal: 10, StartDate: &start, EndDate: &end}, 10, 0, false, 1},
+		{"flight not started", &model.LineItem{ID: "li_future", ImpressionGoal: 100, StartDate: &notStarted, EndDate: &end}, 0, 0.99, true, 1},
+		{"no flight", &model.LineItem{ID: "li_open", ImpressionGoal: 100}, 0, 0.99, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range tt.impressions {
+				_ = r.tracking.IncrementRollups(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: tt.lineItem.ID, Timestamp: now},
+					model.RollupGranularityHour)
+			}
+			s.randFloat = func() float64 { return tt.rand }
+			participates, boost := s.pacing(tt.lineItem, now)
+			if participates != tt.wantParticipate || boost != tt.wantBoost {
+				t.Errorf("pacing() = %v, %v, want %v, %v", participates, boost, tt.wantParticipate, tt.wantBoost)
+			}
+		})
+	}
+}
+
+type recordingNotifier struct {
+	notified []string
+	err      error
+}
+
+func (n *recordingNotifier) NotifyUnderdelivery(_ context.Context, delivery *model.LineItemDelivery) error {
+	n.notified = append(n.notified, delivery.LineItemID)
+	return n.err
+}
+
+func TestDeliveryService_CheckUnderdelivery(t *testing.T) {
+	r := newTestRepos()
+	notifier := &recordingNotifier{err: errors.New("webhook down")}
+	s := NewDeliveryService(r.lineItems, r.tracking, PacingRules{UnderdeliveryTolerance: 0.1, ForecastWarmup: time.Hour}, notifier, zap.NewNop().Sugar())
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	start, end := now.Add(-5*time.Hour), now.Add(5*time.Hour)
+	warmingUp := now.Add(-time.Minute)
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_behind", Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 100, StartDate: &start, EndDate: &end})
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_tolerated", Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 100, StartDate: &start, EndDate: &end})
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_warming_up", Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 100, StartDate: &warmingUp, EndDate: &end})
+	for id, impressions := range map[string]int{"li_behind": 20, "li_tolerated": 46} {
+		for range impressions {
+			_ = r.tracking.IncrementRollups(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: id, Timestamp: now},
+				model.RollupGranularityHour)
+		}
+	}
+
+	behind, _ := s.GetByLineItemID("li_behind")
+	if behind.Expected != 50 || behind.Pace != 0.4 || behind.Forecast != 40 || !behind.Underdelivering {
+		t.Errorf("GetByLineItemID() = %+v, want 40 of 100 impressions forecast", behind)
+	}
+
+	// failed notifications are retried, successful ones are not repeated
+	for range 2 {
+		if err := s.CheckUnderdelivery(context.Background()); err != nil {
+			t.Fatalf("CheckUnderdelivery() error = %v", err)
+		}
+	}
+	notifier.err = nil
+	for range 2 {
+		_ = s.CheckUnderdelivery(context.Background())
+	}
+	if want := []string{"li_behind", "li_behind", "li_behind"}; !slices.Equal(notifier.notified, want) {
+		t.Errorf("notified = %v, want %v", notifier.notified, want)
+	}
+}
+
+func TestDeliveryService_countsImpressionsInFlight(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	lineItems, tracking := repo.NewLineItemRepository(log), repo.NewTrackingEventRepository(log)
+	s := NewDeliveryService(lineItems, tracking, PacingRules{}, nil, log)
+	now := time.Now()
+	start, end := now.Add(-2*time.Hour).Truncate(time.Hour), now.Add(2*time.Hour).Truncate(time.Hour)
+	lineItem := &model.LineItem{ID: "li_1", Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 100, StartDate: &start, EndDate: &end}
+	_ = lineItems.CreateLineItem(lineItem)
+	track := func(timestamp time.Time) {
+		event := &model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: "li_1", Timestamp: timestamp}
+		_ = tracking.IncrementRollups(event, model.RollupGranularityHour)
+		s.OnTrackingEvent(event)
+	}
+	impressions := func() int64 {
+		t.Helper()
+		got, err := s.impressions(lineItem)
+		if err != nil {
+			t.Fatalf("impressions() error = %v", err)
+		}
+		return got
+	}
+
+	// impressions before the flight do not count towards the goal
+	track(start.Add(-time.Hour))
+	track(now)
+	if got := impressions(); got != 1 {
+		t.Errorf("impressions() = %d, want 1 within the flight", got)
+	}
+
+	// served impressions count up without reading the rollups
+	track(now)
+	_ = tracking.IncrementRollups(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: "li_1", Timestamp: now}, model.RollupGranularityHour)
+	if got := impressions(); got != 2 {
+		t.Errorf("impressions() after tracking = %d, want 2", got)
+	}
+
+	// the underdelivery check recounts from the rollups
+	if _, err := s.GetGuaranteed(); err != nil {
+		t.Fatalf("GetGuaranteed() error = %v", err)
+	}
+	if got := impressions(); got != 3 {
+		t.Errorf("impressions() after recount = %d, want 3", got)
+	}
+}
+
+func TestDeliveryService_countsImpressionsInPartialHours(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	lineItems, tracking := repo.NewLineItemRepository(log), repo.NewTrackingEventRepository(log)
+	s := NewDeliveryService(lineItems, tracking, PacingRules{}, nil, log)
+	hour := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	track := func(lineItemID string, timestamp time.Time) {
+		_ = tracking.IncrementRollups(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: lineItemID, Timestamp: timestamp},
+			model.RollupGranularityMinute, model.RollupGranularityHour)
+	}
+	flight := func(id string, start, end time.Time) *model.LineItem {
+		return &model.LineItem{ID: id, Priority: model.LineItemPriorityGuaranteed, ImpressionGoal: 100, StartDate: &start, EndDate: &end}
+	}
+
+	tests := []struct {
+		name     string
+		lineItem *model.LineItem
+		want     int64
+	}{
+		{"starts mid-hour", flight("li_start", hour.Add(30*time.Minute), hour.Add(3*time.Hour)), 15},
+		{"ends mid-hour", flight("li_end", hour.Add(-time.Hour), hour.Add(30*time.Minute)), 9},
+		{"within an hour", flight("li_within", hour.Add(20*time.Minute), hour.Add(40*time.Minute)), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// impressions at 5 past every 10 minutes from 11:00 to 15:00, of which the
+			// hourly rollups of the partial hours also count those outside the flight
+			for at := hour.Add(-time.Hour); at.Before(hour.Add(3 * time.Hour)); at = at.Add(10 * time.Minute) {
+				track(tt.lineItem.ID, at.Add(5*time.Minute))
+			}
+			got, err := s.countImpressions(tt.lineItem)
+			if err != nil {
+				t.Fatalf("countImpressions() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("countImpressions() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// maxForecastDays is the longest flight which is forecast
+const maxForecastDays = 366
+
+// ForecastService estimates the inventory of proposed targeting from the
+// hourly counts of logged ad requests and the targeting of existing line items
+type ForecastService struct {
+	inventoryRepo repo.InventoryRepository
+	lineItemRepo  repo.LineItemRepository
+	// lookback is how many hours of inventory are kept and forecast from
+	lookback time.Duration
+	now      func() time.Time
+	log      *zap.SugaredLogger
+}
+
+// NewForecastService creates a new ForecastService
+func NewForecastService(inventoryRepo repo.InventoryRepository, lineItemRepo repo.LineItemRepository, lookback time.Duration, log *zap.SugaredLogger) *ForecastService {
+	return &ForecastService{
+		inventoryRepo: inventoryRepo,
+		lineItemRepo:  lineItemRepo,
+		lookback:      lookback,
+		now:           time.Now,
+		log:           log,
+	}
+}
+
+// Forecast estimates the impressions of the targeting on the placement for
+// each UTC day of the flight. The daily rate of matching ad requests over the
+// counted hours is projected onto the flight, and the share of them targeted
+// by active, non house line items in flight on a day is contended.
+func (s *ForecastService) Forecast(req model.ForecastRequest) (*model.Forecast, error) {
+	if req.EndDate.Sub(req.StartDate) > maxForecastDays*24*time.Hour {
+		return nil, domain_errors.ErrForecastFlightTooLong
+	}
+	now := s.now()
+	history, err := s.inventoryRepo.GetInventory(repo.GetInventoryFilter{
+		Placement: req.Placement,
+		From:      now.Add(-s.lookback),
+		To:        now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	lineItems, err := s.lineItemRepo.GetLineItems(repo.GetLineItemsFilter{
+		Placement: req.Placement,
+		Status:    model.LineItemStatusActive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	oldest := now
+	var matching []*model.InventoryCount
+	var matchingRequests float64
+	for _, count := range history {
+		if count.Hour.Before(oldest) {
+			oldest = count.Hour
+		}
+		if req.Targeting.MatchesGeo(count.Geo) && req.Targeting.MatchesDevice(count.Device) && req.Targeting.MatchesSegments(count.Segments) {
+			matching = append(matching, count)
+			matchingRequests += count.Requests
+		}
+	}
+	// at least an hour of history, so a few requests are not projected onto whole days
+	span := max(now.Sub(oldest), time.Hour)
+	dailyRate := matchingRequests * float64(24*time.Hour) / float64(span)
+
+	forecast := &model.Forecast{
+		Placement:   req.Placement,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Days:        []model.ForecastDay{},
+		HistoryDays: int(math.Ceil(now.Sub(oldest).Hours() / 24)),
+	}
+	start, end := req.StartDate.UTC(), req.EndDate.UTC()
+	for day := start.Truncate(24 * time.Hour); day.Before(end); day = day.AddDate(0, 0, 1) {
+		from, to := maxTime(day, start), minTime(day.AddDate(0, 0, 1), end)
+		available := dailyRate * to.Sub(from).Hours() / 24
+		contended := available * contendedShare(matching, lineItems, from, to)
+		forecastDay := model.ForecastDay{
+			Date:      day.Format(time.DateOnly),
+			Available: int64(math.Round(available)),
+			Contended: int64(math.Round(contended)),
+		}
+		forecast.Days = append(forecast.Days, forecastDay)
+		forecast.Available += forecastDay.Available
+		forecast.Contended += forecastDay.Contended
+	}
+	s.log.Infow("Inventory forecast",
+		"placement", req.Placement,
+		"counts", len(history),
+		"matching", matchingRequests,
+		"available", forecast.Available,
+		"contended", forecast.Contended,
+	)
+	return forecast, nil
+}
+
+// RunExpiry deletes inventory counts older than the lookback every interval until ctx is done
+func (s *ForecastService) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.inventoryRepo.DeleteBefore(s.now().Add(-s.lookback))
+			if err != nil {
+				s.log.Errorw("error in deleting expired inventory", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				s.log.Infow("Expired inventory deleted", "counts", deleted)
+			}
+		}
+	}
+}
+
+// contendedShare returns the share of the counted requests targeted by a line
+// item competing for the placement between from and to
+func contendedShare(counts []*model.InventoryCount, lineItems []*model.LineItem, from, to time.Time) float64 {
+	if len(counts) == 0 {
+		return 0
+	}
+	var competing []*model.LineItem
+	for _, lineItem := range lineItems {
+		if lineItem.Priority == model.LineItemPriorityHouse {
+			continue
+		}
+		if (lineItem.StartDate == nil || lineItem.StartDate.Before(to)) && (lineItem.EndDate == nil || lineItem.EndDate.After(from)) {
+			competing = append(competing, lineItem)
+		}
+	}
+	var contended, total float64
+	for _, count := range counts {
+		total += count.Requests
+		if slices.ContainsFunc(competing, func(lineItem *model.LineItem) bool { return targetsInventory(lineItem, count) }) {
+			contended += count.Requests
+		}
+	}
+	return contended / total
+}
+
+// targetsInventory reports whether the filters, targeting and blocklist of the line item match the counted requests
+func targetsInventory(lineItem *model.LineItem, count *model.InventoryCount) bool {
+	if lineItem.Blocklist.Blocks(count.Placement, count.Category, count.Keyword, count.Domain) != "" {
+		return false
+	}
+	if count.Category != "" && !slices.Contains(lineItem.Categories, count.Category) {
+		return false
+	}
+	if count.Keyword != "" && !slices.Contains(lineItem.Keywords, count.Keyword) {
+		return false
+	}
+	return lineItem.Targeting.MatchesGeo(count.Geo) && lineItem.Targeting.MatchesDevice(count.Device) &&
+		lineItem.Targeting.MatchesSegments(count.Segments)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
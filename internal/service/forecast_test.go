@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+)
+
+func TestForecastService_Forecast(t *testing.T) {
+	r := newTestRepos()
+	s := NewForecastService(r.inventory, r.lineItems, 7*24*time.Hour, zap.NewNop().Sugar())
+	now := time.Date(2025, 6, 10, 15, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	// two days of history with 12 daily requests from US mobile, US desktop and CA devices each
+	for i := range 24 {
+		at := now.Add(-time.Duration(i*2) * time.Hour)
+		for _, request := range []model.AdRequest{
+			{Placement: "top", Geo: model.Geo{Country: "US"}, Device: model.Device{Type: model.DeviceTypeMobile}},
+			{Placement: "top", Geo: model.Geo{Country: "US"}, Device: model.Device{Type: model.DeviceTypeDesktop}},
+			{Placement: "top", Geo: model.Geo{Country: "CA"}, Device: model.Device{Type: model.DeviceTypeMobile}},
+			{Placement: "sidebar", Geo: model.Geo{Country: "US"}},
+		} {
+			request.Timestamp = at
+			_ = r.inventory.AddInventory(request.InventoryCount())
+		}
+	}
+	_ = r.inventory.AddInventory((&model.AdRequest{Placement: "top", Geo: model.Geo{Country: "US"}, Timestamp: now.Add(-30 * 24 * time.Hour)}).InventoryCount())
+
+	tomorrow := time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)
+	mobileEnd := tomorrow.Add(24 * time.Hour)
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_mobile", Placement: "top", Status: model.LineItemStatusActive,
+		Targeting: model.Targeting{DeviceTypes: []model.DeviceType{model.DeviceTypeMobile}}, EndDate: &mobileEnd})
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_house", Placement: "top", Status: model.LineItemStatusActive, Priority: model.LineItemPriorityHouse})
+	_ = r.lineItems.CreateLineItem(&model.LineItem{ID: "li_paused", Placement: "top", Status: model.LineItemStatusPaused})
+
+	got, err := s.Forecast(model.ForecastRequest{
+		Placement: "top",
+		Targeting: model.Targeting{Countries: []string{"US"}},
+		StartDate: tomorrow.Add(12 * time.Hour),
+		EndDate:   tomorrow.Add(48 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Forecast() error = %v", err)
+	}
+	wantDays := []model.ForecastDay{
+		{Date: "2025-06-11", Available: 12, Contended: 6},
+		{Date: "2025-06-12", Available: 24, Contended: 0},
+	}
+	if !reflect.DeepEqual(got.Days, wantDays) {
+		t.Errorf("Forecast() days = %+v, want %+v", got.Days, wantDays)
+	}
+	if got.Available != 36 || got.Contended != 6 || got.HistoryDays != 2 {
+		t.Errorf("Forecast() = %d available, %d contended from %d days, want 36, 6 from 2 days", got.Available, got.Contended, got.HistoryDays)
+	}
+
+	_, err = s.Forecast(model.ForecastRequest{Placement: "top", StartDate: tomorrow, EndDate: tomorrow.AddDate(2, 0, 0)})
+	if !errors.Is(err, domain_errors.ErrForecastFlightTooLong) {
+		t.Errorf("Forecast() error = %v, want %v", err, domain_errors.ErrForecastFlightTooLong)
+	}
+}
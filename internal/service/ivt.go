@@ -0,0 +1,179 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// InvalidTrafficRules are the thresholds of the invalid traffic filter.
+// Zero values disable the corresponding rule.
+type InvalidTrafficRules struct {
+	BotUserAgents      []string
+	BurstWindow        time.Duration
+	MaxEventsPerIP     int
+	MaxEventsPerUser   int
+	ImpressionLookback time.Duration
+	MinClickDelay      time.Duration
+}
+
+// burstCounter counts events per key in fixed windows
+type burstCounter struct {
+	window    time.Duration
+	counts    map[string]*burstWindow
+	lastSweep time.Time
+}
+
+type burstWindow struct {
+	start time.Time
+	count int
+}
+
+func newBurstCounter(window time.Duration) *burstCounter {
+	return &burstCounter{window: window, counts: make(map[string]*burstWindow)}
+}
+
+// add counts an event of key at now and returns the count within the current window
+func (b *burstCounter) add(key string, now time.Time) int {
+	if now.Sub(b.lastSweep) >= b.window {
+		for k, w := range b.counts {
+			if now.Sub(w.start) >= b.window {
+				delete(b.counts, k)
+			}
+		}
+		b.lastSweep = now
+	}
+	w, ok := b.counts[key]
+	if !ok || now.Sub(w.start) >= b.window {
+		w = &burstWindow{start: now}
+		b.counts[key] = w
+	}
+	w.count++
+	return w.count
+}
+
+// InvalidTrafficFilter flags bot, burst and implausible click events
+type InvalidTrafficFilter struct {
+	trackingRepo repo.TrackingEventRepository
+	rules        InvalidTrafficRules
+	byIP         *burstCounter
+	byUser       *burstCounter
+	mu           sync.Mutex
+	now          func() time.Time
+	log          *zap.SugaredLogger
+}
+
+func NewInvalidTrafficFilter(trackingRepo repo.TrackingEventRepository, rules InvalidTrafficRules, log *zap.SugaredLogger) *InvalidTrafficFilter {
+	bots := make([]string, 0, len(rules.BotUserAgents))
+	for _, ua := range rules.BotUserAgents {
+		if ua = strings.ToLower(strings.TrimSpace(ua)); ua != "" {
+			bots = append(bots, ua)
+		}
+	}
+	rules.BotUserAgents = bots
+	return &InvalidTrafficFilter{
+		trackingRepo: trackingRepo,
+		rules:        rules,
+		byIP:         newBurstCounter(rules.BurstWindow),
+		byUser:       newBurstCounter(rules.BurstWindow),
+		now:          time.Now,
+		log:          log,
+	}
+}
+
+// Inspect returns the reason the event is invalid traffic, or an empty reason for valid events.
+// Every inspected event counts towards the burst thresholds.
+func (f *InvalidTrafficFilter) Inspect(event *model.TrackingEvent) (model.InvalidTrafficReason, error) {
+	if f.isBot(event.UserAgent) {
+		return model.InvalidTrafficReasonBotUserAgent, nil
+	}
+	if reason := f.burst(event); reason != "" {
+		return reason, nil
+	}
+	if event.EventType == model.TrackingEventTypeClick && (event.UserID != "" || event.IP != "") {
+		return f.inspectClick(event)
+	}
+	return "", nil
+}
+
+// visitor identifies the user of an event, by its user ID or else by its IP
+// and user agent, so anonymous traffic is limited as well
+func visitor(event *model.TrackingEvent) string {
+	if event.UserID != "" {
+		return event.UserID
+	}
+	if event.IP == "" {
+		return ""
+	}
+	return event.IP + "|" + event.UserAgent
+}
+
+func (f *InvalidTrafficFilter) isBot(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, bot := range f.rules.BotUserAgents {
+		if strings.Contains(ua, bot) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *InvalidTrafficFilter) burst(event *model.TrackingEvent) model.InvalidTrafficReason {
+	if f.rules.BurstWindow <= 0 {
+		return ""
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	var reason model.InvalidTrafficReason
+	if event.IP != "" && f.rules.MaxEventsPerIP > 0 && f.byIP.add(event.IP, now) > f.rules.MaxEventsPerIP {
+		reason = model.InvalidTrafficReasonIPBurst
+	}
+	if user := visitor(event); user != "" && f.rules.MaxEventsPerUser > 0 && f.byUser.add(user, now) > f.rules.MaxEventsPerUser && reason == "" {
+		reason = model.InvalidTrafficReasonUserBurst
+	}
+	return reason
+}
+
+// inspectClick requires a valid impression of the same user and line item
+// within the lookback, which happened at least MinClickDelay before the click.
+// Anonymous clicks require an impression of the same IP and user agent.
+func (f *InvalidTrafficFilter) inspectClick(click *model.TrackingEvent) (model.InvalidTrafficReason, error) {
+	if f.rules.ImpressionLookback <= 0 {
+		return "", nil
+	}
+	filter := repo.GetTrackingEventsFilter{
+		LineItemID: click.LineItemID,
+		EventType:  model.TrackingEventTypeImpression,
+		UserID:     click.UserID,
+		From:       click.Timestamp.Add(-f.rules.ImpressionLookback),
+		To:         click.Timestamp.Add(time.Nanosecond),
+	}
+	if click.UserID == "" {
+		filter.IP, filter.UserAgent = click.IP, click.UserAgent
+	}
+	impressions, _, err := f.trackingRepo.GetTrackingEvents(filter)
+	if err != nil {
+		return "", err
+	}
+
+	var last *model.TrackingEvent
+	for _, impression := range impressions {
+		if impression.IsValid() && (last == nil || impression.Timestamp.After(last.Timestamp)) {
+			last = impression
+		}
+	}
+	if last == nil {
+		return model.InvalidTrafficReasonClickWithoutImpression, nil
+	}
+	if click.Timestamp.Sub(last.Timestamp) < f.rules.MinClickDelay {
+		return model.InvalidTrafficReasonFastClick, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+func TestInvalidTrafficFilter_Inspect(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rules := InvalidTrafficRules{
+		BotUserAgents:      []string{"Googlebot", "curl"},
+		BurstWindow:        time.Minute,
+		MaxEventsPerIP:     2,
+		ImpressionLookback: time.Hour,
+		MinClickDelay:      time.Second,
+	}
+	impression := &model.TrackingEvent{
+		EventType:  model.TrackingEventTypeImpression,
+		LineItemID: "li_1",
+		UserID:     "u_1",
+		IP:         "10.0.0.9",
+		UserAgent:  "Mozilla/5.0",
+		Timestamp:  base,
+	}
+	click := func(user string, after time.Duration) *model.TrackingEvent {
+		return &model.TrackingEvent{
+			EventType:  model.TrackingEventTypeClick,
+			LineItemID: "li_1",
+			UserID:     user,
+			Timestamp:  base.Add(after),
+		}
+	}
+	anonymousClick := func(userAgent string, after time.Duration) *model.TrackingEvent {
+		return &model.TrackingEvent{
+			EventType:  model.TrackingEventTypeClick,
+			LineItemID: "li_1",
+			IP:         "10.0.0.9",
+			UserAgent:  userAgent,
+			Timestamp:  base.Add(after),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		event *model.TrackingEvent
+		want  model.InvalidTrafficReason
+	}{
+		{"bot user agent", &model.TrackingEvent{EventType: model.TrackingEventTypeImpression, UserAgent: "Mozilla/5.0 (compatible; Googlebot/2.1)"}, model.InvalidTrafficReasonBotUserAgent},
+		{"valid click", click("u_1", 5*time.Second), ""},
+		{"click too fast after impression", click("u_1", 100*time.Millisecond), model.InvalidTrafficReasonFastClick},
+		{"click without impression", click("u_2", 5*time.Second), model.InvalidTrafficReasonClickWithoutImpression},
+		{"click after impression lookback", click("u_1", 2*time.Hour), model.InvalidTrafficReasonClickWithoutImpression},
+		{"anonymous click", anonymousClick("Mozilla/5.0", 5*time.Second), ""},
+		{"anonymous click too fast", anonymousClick("Mozilla/5.0", 100*time.Millisecond), model.InvalidTrafficReasonFastClick},
+		{"anonymous click of another client", anonymousClick("Other/1.0", 5*time.Second), model.InvalidTrafficReasonClickWithoutImpression},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trackingRepo := repo.NewTrackingEventRepository(log)
+			_ = trackingRepo.CreateTrackingEvent(impression)
+			f := NewInvalidTrafficFilter(trackingRepo, rules, log)
+
+			got, err := f.Inspect(tt.event)
+			if err != nil {
+				t.Fatalf("Inspect() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Inspect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("ip burst", func(t *testing.T) {
+		f := NewInvalidTrafficFilter(repo.NewTrackingEventRepository(log), rules, log)
+		now := base
+		f.now = func() time.Time { return now }
+		want := []model.InvalidTrafficReason{"", "", model.InvalidTrafficReasonIPBurst}
+		for i, w := range want {
+			got, _ := f.Inspect(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, IP: "10.0.0.1"})
+			if got != w {
+				t.Errorf("event %d: Inspect() = %q, want %q", i, got, w)
+			}
+		}
+		now = now.Add(time.Minute)
+		if got, _ := f.Inspect(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, IP: "10.0.0.1"}); got != "" {
+			t.Errorf("Inspect() in next window = %q, want valid", got)
+		}
+	})
+
+	t.Run("anonymous user burst", func(t *testing.T) {
+		f := NewInvalidTrafficFilter(repo.NewTrackingEventRepository(log), InvalidTrafficRules{BurstWindow: time.Minute, MaxEventsPerUser: 1}, log)
+		f.now = func() time.Time { return base }
+		event := func(userAgent string) *model.TrackingEvent {
+			return &model.TrackingEvent{EventType: model.TrackingEventTypeImpression, IP: "10.0.0.1", UserAgent: userAgent}
+		}
+		want := []model.InvalidTrafficReason{"", "", model.InvalidTrafficReasonUserBurst}
+		for i, userAgent := range []string{"Mozilla/5.0", "Other/1.0", "Mozilla/5.0"} {
+			if got, _ := f.Inspect(event(userAgent)); got != want[i] {
+				t.Errorf("event %d: Inspect() = %q, want %q", i, got, want[i])
+			}
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"slices"
 	"time"
 
 	"sweng-task/internal/domain_errors"
@@ -13,34 +14,91 @@ import (
 
 // LineItemService provides operations for line items
 type LineItemService struct {
-	repo repo.LineItemRepository
-	log  *zap.SugaredLogger
+	repo              repo.LineItemRepository
+	advertiserService *AdvertiserService
+	campaignService   *CampaignService
+	creativeService   *CreativeService
+	audienceService   *AudienceService
+	dealService       *DealService
+	now               func() time.Time
+	log               *zap.SugaredLogger
 }
 
 // NewLineItemService creates a new LineItemService
-func NewLineItemService(repo repo.LineItemRepository, log *zap.SugaredLogger) *LineItemService {
+func NewLineItemService(repo repo.LineItemRepository, advertiserService *AdvertiserService, campaignService *CampaignService, creativeService *CreativeService, audienceService *AudienceService, dealService *DealService, log *zap.SugaredLogger) *LineItemService {
 	return &LineItemService{
-		repo: repo,
-		log:  log,
+		repo:              repo,
+		advertiserService: advertiserService,
+		campaignService:   campaignService,
+		creativeService:   creativeService,
+		audienceService:   audienceService,
+		dealService:       dealService,
+		now:               time.Now,
+		log:               log,
 	}
 }
 
-// Create creates a new line item
+// Create creates a new line item for an existing, active advertiser,
+// optionally under one of the advertiser's campaigns. Targeted audiences must
+// belong to the advertiser, and the deal, if any, must allow the line item.
 func (s *LineItemService) Create(item model.LineItemCreate) (*model.LineItem, error) {
+	if err := s.advertiserService.CheckActive(item.AdvertiserID); err != nil {
+		return nil, err
+	}
+	if item.CampaignID != "" {
+		if err := s.campaignService.CheckOwnership(item.CampaignID, item.AdvertiserID); err != nil {
+			return nil, err
+		}
+	}
+	if item.StartDate != nil && item.EndDate != nil && !item.EndDate.After(*item.StartDate) {
+		return nil, domain_errors.ErrLineItemInvalidFlight
+	}
+	if err := s.audienceService.CheckTargeting(item.Targeting, item.AdvertiserID); err != nil {
+		return nil, err
+	}
+	priority := item.Priority
+	if priority == "" {
+		priority = model.LineItemPriorityStandard
+	}
+	bid, budget := item.Bid, item.Budget
+	if priority == model.LineItemPriorityHouse {
+		// house ads are free
+		bid, budget = 0, 0
+	}
+	if item.DealID != "" {
+		var err error
+		if bid, err = s.dealService.DealBid(item.DealID, item.AdvertiserID, item.Placement, item.Bid); err != nil {
+			return nil, err
+		}
+	}
 	now := time.Now()
+	rotation := item.CreativeRotation
+	if rotation == "" {
+		rotation = model.CreativeRotationWeighted
+	}
 
 	lineItem := &model.LineItem{
-		ID:           "li_" + uuid.New().String(),
-		Name:         item.Name,
-		AdvertiserID: item.AdvertiserID,
-		Bid:          item.Bid,
-		Budget:       item.Budget,
-		Placement:    item.Placement,
-		Categories:   item.Categories,
-		Keywords:     item.Keywords,
-		Status:       model.LineItemStatusActive,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+		ID:                 "li_" + uuid.New().String(),
+		Name:               item.Name,
+		AdvertiserID:       item.AdvertiserID,
+		CampaignID:         item.CampaignID,
+		Bid:                bid,
+		Budget:             budget,
+		Placement:          item.Placement,
+		Categories:         item.Categories,
+		Keywords:           item.Keywords,
+		ClickLookbackHours: item.ClickLookbackHours,
+		ViewLookbackHours:  item.ViewLookbackHours,
+		CreativeRotation:   rotation,
+		Targeting:          item.Targeting,
+		DealID:             item.DealID,
+		Priority:           priority,
+		ImpressionGoal:     item.ImpressionGoal,
+		StartDate:          item.StartDate,
+		EndDate:            item.EndDate,
+		Status:             model.LineItemStatusActive,
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
 
 	err := s.repo.CreateLineItem(lineItem)
@@ -68,54 +126,208 @@ func (s *LineItemService) GetByID(id string) (*model.LineItem, error) {
 	return lineItem, nil
 }
 
+// AttachCreative attaches a creative of the line item's advertiser, or updates
+// its rotation weight when already attached. A zero weight defaults to 1.
+func (s *LineItemService) AttachCreative(id string, attached model.LineItemCreative) (*model.LineItem, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.creativeService.CheckAttachable(attached.CreativeID, existing.AdvertiserID, existing.Placement); err != nil {
+		return nil, err
+	}
+	if attached.Weight == 0 {
+		attached.Weight = 1
+	}
+
+	lineItem := *existing
+	lineItem.Creatives = make([]model.LineItemCreative, 0, len(existing.Creatives)+1)
+	for _, c := range existing.Creatives {
+		if c.CreativeID != attached.CreativeID {
+			lineItem.Creatives = append(lineItem.Creatives, c)
+		}
+	}
+	lineItem.Creatives = append(lineItem.Creatives, attached)
+	return s.update(&lineItem)
+}
+
+// DetachCreative removes a creative from the line item's rotation
+func (s *LineItemService) DetachCreative(id, creativeID string) (*model.LineItem, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	lineItem := *existing
+	lineItem.Creatives = make([]model.LineItemCreative, 0, len(existing.Creatives))
+	for _, c := range existing.Creatives {
+		if c.CreativeID != creativeID {
+			lineItem.Creatives = append(lineItem.Creatives, c)
+		}
+	}
+	if len(lineItem.Creatives) == len(existing.Creatives) {
+		return nil, domain_errors.ErrCreativeNotFound
+	}
+	return s.update(&lineItem)
+}
+
+// UpdateBlocklist replaces the blocklist of a line item
+func (s *LineItemService) UpdateBlocklist(id string, blocklist model.Blocklist) (*model.LineItem, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	lineItem := *existing
+	lineItem.Blocklist = blocklist
+	return s.update(&lineItem)
+}
+
+func (s *LineItemService) update(lineItem *model.LineItem) (*model.LineItem, error) {
+	lineItem.UpdatedAt = time.Now()
+	if err := s.repo.UpdateLineItem(lineItem); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Line item updated",
+		"id", lineItem.ID,
+		"creatives", len(lineItem.Creatives),
+	)
+	return lineItem, nil
+}
+
 // GetAll retrieves all line items, optionally filtered by advertiser ID and placement
 func (s *LineItemService) GetAll(advertiserID, placement string) ([]*model.LineItem, error) {
 	return s.repo.GetLineItems(repo.GetLineItemsFilter{AdvertiserID: advertiserID, Placement: placement})
 }
 
+// Reasons line items are excluded from the candidates of an ad request
+const (
+	exclusionOutOfFlight        = "out_of_flight"
+	exclusionCampaignNotServing = "campaign_not_serving"
+	exclusionNoApprovedCreative = "no_approved_creative"
+	exclusionCategoryMismatch   = "category_mismatch"
+	exclusionKeywordMismatch    = "keyword_mismatch"
+	exclusionGeoMismatch        = "geo_mismatch"
+	exclusionDeviceMismatch     = "device_mismatch"
+	exclusionSegmentMismatch    = "segment_mismatch"
+	exclusionDealMismatch       = "deal_mismatch"
+	exclusionPrivateAuction     = "private_auction"
+)
+
 // FindMatchingLineItems finds line items matching the given placement and filters
 // This method will be used by the AdService when implementing the ad selection logic
-func (s *LineItemService) FindMatchingLineItems(placement string, category, keyword string) ([]*model.LineItem, error) {
+// Line items out of their own flight, line items of campaigns which are paused,
+// completed or out of flight, line items without an approved creative, line
+// items of deals the request does not carry, open auction line items on private
+// auctions, line items whose geo, device or audience segment targeting does not
+// match and line items whose own or advertiser blocklist blocks the request are
+// excluded. The number of excluded line items is returned per reason.
+func (s *LineItemService) FindMatchingLineItems(q AdQuery) ([]*model.LineItem, map[string]int, error) {
 	lineItems, err := s.repo.GetLineItems(repo.GetLineItemsFilter{
-		Placement: placement,
+		Placement: q.Placement,
 		Status:    model.LineItemStatusActive,
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	now := s.now()
+	campaignServing := make(map[string]bool)
+	advertiserBlocks := make(map[string]model.BlockReason)
+	excluded := make(map[string]int)
 	result := make([]*model.LineItem, 0)
 	for _, item := range lineItems {
+		if !item.InFlight(now) {
+			excluded[exclusionOutOfFlight]++
+			continue
+		}
+		if item.DealID != "" && !slices.Contains(q.DealIDs, item.DealID) {
+			excluded[exclusionDealMismatch]++
+			continue
+		}
+		if item.DealID == "" && q.PrivateAuction {
+			excluded[exclusionPrivateAuction]++
+			continue
+		}
+		if item.CampaignID != "" {
+			serving, ok := campaignServing[item.CampaignID]
+			if !ok {
+				campaign, err := s.campaignService.GetByID(item.CampaignID)
+				serving = err == nil && campaign.IsServing(now)
+				campaignServing[item.CampaignID] = serving
+			}
+			if !serving {
+				excluded[exclusionCampaignNotServing]++
+				continue
+			}
+		}
+		if !s.creativeService.HasApproved(item) {
+			excluded[exclusionNoApprovedCreative]++
+			continue
+		}
+
 		// Apply category filter if specified
-		if category != "" {
+		if q.Category != "" {
 			categoryFound := false
 			for _, cat := range item.Categories {
-				if cat == category {
+				if cat == q.Category {
 					categoryFound = true
 					break
 				}
 			}
 			if !categoryFound {
+				excluded[exclusionCategoryMismatch]++
 				continue
 			}
 		}
 
 		// Apply keyword filter if specified
-		if keyword != "" {
+		if q.Keyword != "" {
 			keywordFound := false
 			for _, kw := range item.Keywords {
-				if kw == keyword {
+				if kw == q.Keyword {
 					keywordFound = true
 					break
 				}
 			}
 			if !keywordFound {
+				excluded[exclusionKeywordMismatch]++
 				continue
 			}
 		}
 
+		// Apply geo and device targeting
+		if !item.Targeting.MatchesGeo(q.Geo) {
+			excluded[exclusionGeoMismatch]++
+			continue
+		}
+		if !item.Targeting.MatchesDevice(q.Device) {
+			excluded[exclusionDeviceMismatch]++
+			continue
+		}
+		if !item.Targeting.MatchesSegments(q.Segments) {
+			excluded[exclusionSegmentMismatch]++
+			continue
+		}
+
+		// Apply blocklists of the line item and its advertiser
+		reason := item.Blocklist.Blocks(q.Placement, q.Category, q.Keyword, q.Domain)
+		if reason == "" {
+			var ok bool
+			if reason, ok = advertiserBlocks[item.AdvertiserID]; !ok {
+				if advertiser, err := s.advertiserService.GetByID(item.AdvertiserID); err == nil {
+					reason = advertiser.Blocklist.Blocks(q.Placement, q.Category, q.Keyword, q.Domain)
+				}
+				advertiserBlocks[item.AdvertiserID] = reason
+			}
+		}
+		if reason != "" {
+			excluded[string(reason)]++
+			continue
+		}
+
 		result = append(result, item)
 	}
 
-	return result, nil
+	return result, excluded, nil
 }
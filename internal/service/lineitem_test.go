@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+)
+
+func TestLineItemService_FindMatchingLineItems_Blocklist(t *testing.T) {
+	s, r := newTestAdService()
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{
+		ID:        "adv_safe",
+		Status:    model.AdvertiserStatusActive,
+		Blocklist: model.Blocklist{Categories: []string{"News"}, Domains: []string{"tabloid.example"}},
+	})
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_open", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_safe", AdvertiserID: "adv_safe", Bid: 10, Budget: 100, Placement: "top", Categories: []string{"news", "sports"}, Status: model.LineItemStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_open", AdvertiserID: "adv_open", Bid: 10, Budget: 100, Placement: "top", Categories: []string{"news", "sports"}, Keywords: []string{"crash"}, Status: model.LineItemStatusActive,
+		Blocklist: model.Blocklist{Keywords: []string{"crash"}}})
+
+	tests := []struct {
+		name         string
+		q            AdQuery
+		want         []string
+		wantExcluded map[string]int
+	}{
+		{"nothing blocked", AdQuery{Placement: "top", Category: "sports"}, []string{"li_open", "li_safe"}, map[string]int{}},
+		{"advertiser category blocked case-insensitively", AdQuery{Placement: "top", Category: "news"}, []string{"li_open"}, map[string]int{"blocked_category": 1}},
+		{"advertiser domain blocks subdomains", AdQuery{Placement: "top", Domain: "www.tabloid.example"}, []string{"li_open"}, map[string]int{"blocked_domain": 1}},
+		{"line item keyword blocked", AdQuery{Placement: "top", Keyword: "crash"}, nil, map[string]int{"keyword_mismatch": 1, "blocked_keyword": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lineItems, excluded, err := s.lineItemService.FindMatchingLineItems(tt.q)
+			if err != nil {
+				t.Fatalf("FindMatchingLineItems() error = %v", err)
+			}
+			var got []string
+			for _, li := range lineItems {
+				got = append(got, li.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FindMatchingLineItems() = %v, want %v", got, tt.want)
+			}
+			if !maps.Equal(excluded, tt.wantExcluded) {
+				t.Errorf("FindMatchingLineItems() excluded = %v, want %v", excluded, tt.wantExcluded)
+			}
+		})
+	}
+}
+
+func TestLineItemService_Create_Deal(t *testing.T) {
+	s, r := newTestAdService()
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	_ = r.deals.CreateDeal(&model.Deal{ID: "deal_fixed", PriceType: model.DealPriceTypeFixed, Price: 5, Placements: []string{"top"}})
+	_ = r.deals.CreateDeal(&model.Deal{ID: "deal_floor", PriceType: model.DealPriceTypeFloor, Price: 5, AdvertiserIDs: []string{"adv_other"}})
+	_ = r.deals.CreateDeal(&model.Deal{ID: "deal_open_floor", PriceType: model.DealPriceTypeFloor, Price: 5})
+
+	tests := []struct {
+		name    string
+		dealID  string
+		bid     float64
+		wantBid float64
+		wantErr error
+	}{
+		{"fixed deal bids the deal price", "deal_fixed", 8, 5, nil},
+		{"floor deal keeps bid", "deal_open_floor", 8, 8, nil},
+		{"bid below floor", "deal_open_floor", 4, 0, domain_errors.ErrDealBidBelowFloor},
+		{"advertiser not allowed", "deal_floor", 8, 0, domain_errors.ErrDealNotAllowed},
+		{"unknown deal", "deal_unknown", 8, 0, domain_errors.ErrDealNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lineItem, err := s.lineItemService.Create(model.LineItemCreate{
+				Name: tt.name, AdvertiserID: "adv_1", Bid: tt.bid, Budget: 100, Placement: "top", DealID: tt.dealID,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (lineItem.Bid != tt.wantBid || lineItem.DealID != tt.dealID) {
+				t.Errorf("Create() bid = %v, deal = %q, want %v, %q", lineItem.Bid, lineItem.DealID, tt.wantBid, tt.dealID)
+			}
+		})
+	}
+}
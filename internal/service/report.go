@@ -0,0 +1,174 @@
+package service
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// ReportService aggregates tracking events into line item performance reports
+type ReportService struct {
+	trackingRepo repo.TrackingEventRepository
+	lineItemRepo repo.LineItemRepository
+	log          *zap.SugaredLogger
+}
+
+func NewReportService(trackingRepo repo.TrackingEventRepository, lineItemRepo repo.LineItemRepository, log *zap.SugaredLogger) *ReportService {
+	return &ReportService{
+		trackingRepo: trackingRepo,
+		lineItemRepo: lineItemRepo,
+		log:          log,
+	}
+}
+
+type ReportQuery struct {
+	GroupBy      []model.ReportDimension
+	Interval     model.ReportInterval
+	From         time.Time
+	To           time.Time
+	LineItemID   string
+	AdvertiserID string
+}
+
+type reportKey struct {
+	period       time.Time
+	lineItemID   string
+	advertiserID string
+	placement    string
+}
+
+type reportGroup struct {
+	row       *model.ReportRow
+	lineItems map[string]struct{}
+}
+
+// Generate builds report rows for valid events matching q.
+// Spend is the cost charged for the impressions, the bid spent on served ads
+// or the clearing price of won external auctions. Revenue is the order value
+// of attributed conversions. Remaining budget is the current budget of the
+// line items contributing to a row which is not reserved for pending bids.
+func (s *ReportService) Generate(q ReportQuery) ([]*model.ReportRow, error) {
+	events, _, err := s.trackingRepo.GetTrackingEvents(repo.GetTrackingEventsFilter{
+		LineItemID: q.LineItemID,
+		From:       q.From,
+		To:         q.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lineItems := make(map[string]*model.LineItem)
+	groups := make(map[reportKey]*reportGroup)
+	for _, event := range events {
+		if event.LineItemID == "" || !event.IsValid() {
+			// unattributed conversion or invalid traffic
+			continue
+		}
+		lineItem, ok := lineItems[event.LineItemID]
+		if !ok {
+			if lineItem, err = s.lineItemRepo.GetLineItemById(event.LineItemID); err != nil {
+				return nil, err
+			}
+			lineItems[event.LineItemID] = lineItem
+		}
+		if q.AdvertiserID != "" && (lineItem == nil || lineItem.AdvertiserID != q.AdvertiserID) {
+			continue
+		}
+
+		key := s.groupKey(q, event, lineItem)
+		group, ok := groups[key]
+		if !ok {
+			group = &reportGroup{
+				row: &model.ReportRow{
+					LineItemID:   key.lineItemID,
+					AdvertiserID: key.advertiserID,
+					Placement:    key.placement,
+				},
+				lineItems: make(map[string]struct{}),
+			}
+			if q.Interval != model.ReportIntervalNone {
+				period := key.period
+				group.row.Period = &period
+			}
+			groups[key] = group
+		}
+
+		switch event.EventType {
+		case model.TrackingEventTypeImpression:
+			group.row.Impressions++
+			group.row.Spend += event.Cost
+		case model.TrackingEventTypeClick:
+			group.row.Clicks++
+		case model.TrackingEventTypeConversion:
+			group.row.Conversions++
+			group.row.Revenue += event.OrderValue
+		default:
+			group.row.VideoCounts.Add(event.EventType)
+		}
+		if lineItem != nil {
+			group.lineItems[lineItem.ID] = struct{}{}
+		}
+	}
+
+	result := make([]*model.ReportRow, 0, len(groups))
+	for _, group := range groups {
+		row := group.row
+		for id := range group.lineItems {
+			row.RemainingBudget += lineItems[id].Budget - lineItems[id].Reserved
+		}
+		if row.Impressions > 0 {
+			row.CTR = float64(row.Clicks) / float64(row.Impressions)
+			row.ECPM = row.Spend / float64(row.Impressions) * 1000
+		}
+		if row.Spend > 0 {
+			row.ROAS = row.Revenue / row.Spend
+		}
+		if row.Clicks > 0 {
+			row.CVR = float64(row.Conversions) / float64(row.Clicks)
+		}
+		result = append(result, row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Period != nil && !a.Period.Equal(*b.Period) {
+			return a.Period.Before(*b.Period)
+		}
+		if a.AdvertiserID != b.AdvertiserID {
+			return a.AdvertiserID < b.AdvertiserID
+		}
+		if a.LineItemID != b.LineItemID {
+			return a.LineItemID < b.LineItemID
+		}
+		return a.Placement < b.Placement
+	})
+	return result, nil
+}
+
+func (s *ReportService) groupKey(q ReportQuery, event *model.TrackingEvent, lineItem *model.LineItem) reportKey {
+	var key reportKey
+	switch q.Interval {
+	case model.ReportIntervalHour:
+		key.period = event.Timestamp.UTC().Truncate(time.Hour)
+	case model.ReportIntervalDay:
+		ts := event.Timestamp.UTC()
+		key.period = time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if slices.Contains(q.GroupBy, model.ReportDimensionLineItem) {
+		key.lineItemID = event.LineItemID
+	}
+	if slices.Contains(q.GroupBy, model.ReportDimensionAdvertiser) && lineItem != nil {
+		key.advertiserID = lineItem.AdvertiserID
+	}
+	if slices.Contains(q.GroupBy, model.ReportDimensionPlacement) {
+		key.placement = event.Placement
+		if key.placement == "" && lineItem != nil {
+			key.placement = lineItem.Placement
+		}
+	}
+	return key
+}
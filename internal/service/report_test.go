@@ -0,0 +1,133 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+func TestReportService_Generate(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	trackingRepo := repo.NewTrackingEventRepository(log)
+	lineItemRepo := repo.NewLineItemRepository(log)
+	_ = lineItemRepo.CreateLineItem(&model.LineItem{ID: "li_1", AdvertiserID: "adv_1", Bid: 2, Budget: 100, Placement: "top"})
+	_ = lineItemRepo.CreateLineItem(&model.LineItem{ID: "li_2", AdvertiserID: "adv_1", Bid: 4, Budget: 50, Placement: "side"})
+
+	base := time.Date(2025, 1, 1, 10, 15, 0, 0, time.UTC)
+	events := []struct {
+		lineItemID string
+		eventType  model.TrackingEventType
+		offset     time.Duration
+	}{
+		{"li_1", model.TrackingEventTypeImpression, 0},
+		{"li_1", model.TrackingEventTypeImpression, time.Minute},
+		{"li_1", model.TrackingEventTypeImpression, time.Hour},
+		{"li_1", model.TrackingEventTypeImpression, time.Hour},
+		{"li_1", model.TrackingEventTypeClick, 2 * time.Minute},
+		{"li_1", model.TrackingEventTypeConversion, 3 * time.Minute},
+		{"li_2", model.TrackingEventTypeImpression, 0},
+		{"li_2", model.TrackingEventTypeClick, time.Minute},
+	}
+	// impressions are charged the bids of their line items
+	bids := map[string]float64{"li_1": 2, "li_2": 4}
+	for _, e := range events {
+		event := &model.TrackingEvent{
+			EventType:  e.eventType,
+			LineItemID: e.lineItemID,
+			Timestamp:  base.Add(e.offset),
+		}
+		if e.eventType == model.TrackingEventTypeImpression {
+			event.Cost = bids[e.lineItemID]
+		}
+		_ = trackingRepo.CreateTrackingEvent(event)
+	}
+	s := NewReportService(trackingRepo, lineItemRepo, log)
+
+	t.Run("grouped by advertiser", func(t *testing.T) {
+		rows, err := s.Generate(ReportQuery{GroupBy: []model.ReportDimension{model.ReportDimensionAdvertiser}})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if len(rows) != 1 {
+			t.Fatalf("Generate() returned %d rows, want 1", len(rows))
+		}
+		got := *rows[0]
+		want := model.ReportRow{
+			AdvertiserID:    "adv_1",
+			Impressions:     5,
+			Clicks:          2,
+			Conversions:     1,
+			CTR:             0.4,
+			CVR:             0.5,
+			Spend:           12,
+			ECPM:            2400,
+			RemainingBudget: 150,
+		}
+		if got != want {
+			t.Errorf("Generate() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("grouped by line item and hour", func(t *testing.T) {
+		rows, err := s.Generate(ReportQuery{
+			GroupBy:  []model.ReportDimension{model.ReportDimensionLineItem},
+			Interval: model.ReportIntervalHour,
+		})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if len(rows) != 3 {
+			t.Fatalf("Generate() returned %d rows, want 3", len(rows))
+		}
+		first := rows[0]
+		if first.LineItemID != "li_1" || !first.Period.Equal(base.Truncate(time.Hour)) || first.Impressions != 2 || first.Clicks != 1 {
+			t.Errorf("first row = %+v, unexpected", first)
+		}
+		last := rows[2]
+		if last.LineItemID != "li_1" || !last.Period.Equal(base.Truncate(time.Hour).Add(time.Hour)) || last.Impressions != 2 {
+			t.Errorf("last row = %+v, unexpected", last)
+		}
+	})
+}
+
+func TestReportService_Generate_ChargedSpend(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	ads, r := newTestAdService()
+	tracking := NewTrackingService(r.tracking, nil, nil, nil, ads, log)
+	auction := NewAuctionService(ads, repo.NewBidRepository(log), tracking, log)
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_1", AdvertiserID: "adv_1", Bid: 0.002, Budget: 1, Placement: "top", Status: model.LineItemStatusActive})
+
+	// one served ad charged at the bid, one auction won below the bid and one pending
+	assertServed(t, ads, AdQuery{Placement: "top", Limit: 1}, "li_1")
+	if err := tracking.Track(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: "li_1", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	bids, err := auction.Bid("req_1", "1", 0, AdQuery{Placement: "top", Limit: 1})
+	if err != nil || len(bids) != 1 {
+		t.Fatalf("Bid() = %v, %v, want one bid", bids, err)
+	}
+	price := 1.2
+	if _, err := auction.Win(bids[0].ID, &price); err != nil {
+		t.Fatalf("Win() error = %v", err)
+	}
+	if _, err := auction.Bid("req_2", "1", 0, AdQuery{Placement: "top", Limit: 1}); err != nil {
+		t.Fatalf("Bid() error = %v", err)
+	}
+
+	rows, err := NewReportService(r.tracking, r.lineItems, log).Generate(ReportQuery{GroupBy: []model.ReportDimension{model.ReportDimensionLineItem}})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Generate() = %v, %v, want one row", rows, err)
+	}
+	row := rows[0]
+	if row.Impressions != 2 || !budgetEqual(row.Spend, 0.0032) || !budgetEqual(row.ECPM, 1.6) {
+		t.Errorf("Generate() = %d impressions, spend %v, eCPM %v, want 2 impressions charged 0.002 and 0.0012", row.Impressions, row.Spend, row.ECPM)
+	}
+	if !budgetEqual(row.RemainingBudget, 1-0.0032-0.002) {
+		t.Errorf("Generate() remaining budget = %v, want the budget without the pending reservation", row.RemainingBudget)
+	}
+}
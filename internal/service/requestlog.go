@@ -0,0 +1,138 @@
+package service
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// AdRequestSink receives logged ad requests
+type AdRequestSink interface {
+	Publish(request *model.AdRequest) error
+}
+
+// AdRequestLog logs a sample of the ad requests to the request log sink, and
+// counts them in the hourly inventory which is forecast from
+type AdRequestLog struct {
+	inventory repo.InventoryRepository
+	sink      AdRequestSink
+	// sampleRate is the share of ad requests logged, between 0 and 1
+	sampleRate float64
+	randFloat  func() float64
+	log        *zap.SugaredLogger
+}
+
+// NewAdRequestLog creates a new AdRequestLog, sink may be nil when requests
+// are only counted in the inventory
+func NewAdRequestLog(inventory repo.InventoryRepository, sink AdRequestSink, sampleRate float64, log *zap.SugaredLogger) *AdRequestLog {
+	return &AdRequestLog{
+		inventory:  inventory,
+		sink:       sink,
+		sampleRate: min(max(sampleRate, 0), 1),
+		randFloat:  rand.Float64,
+		log:        log,
+	}
+}
+
+// sampled reports whether an ad request is logged
+func (l *AdRequestLog) sampled() bool {
+	return l.sampleRate >= 1 || l.randFloat() < l.sampleRate
+}
+
+// record counts the request in the inventory and publishes it to the sink
+func (l *AdRequestLog) record(request *model.AdRequest) {
+	request.SampleRate = l.sampleRate
+	if err := l.inventory.AddInventory(request.InventoryCount()); err != nil {
+		l.log.Errorw("error in counting ad request inventory",
+			"id", request.ID,
+			"error", err)
+	}
+	if l.sink == nil {
+		return
+	}
+	if err := l.sink.Publish(request); err != nil {
+		l.log.Warnw("ad request delivery failed",
+			"id", request.ID,
+			"error", err)
+	}
+}
+
+// newAdRequest returns the log record of an ad request with its ranked candidates and ads
+func newAdRequest(q AdQuery, timestamp time.Time, candidates int, excluded map[string]int, ranked []scoredItem, ads []*model.Ad) *model.AdRequest {
+	request := &model.AdRequest{
+		ID:                          "req_" + uuid.New().String(),
+		Timestamp:                   timestamp,
+		Placement:                   q.Placement,
+		Category:                    q.Category,
+		Keyword:                     q.Keyword,
+		Domain:                      q.Domain,
+		Geo:                         q.Geo,
+		Device:                      q.Device,
+		Segments:                    q.Segments,
+		Limit:                       q.Limit,
+		BidFloor:                    q.BidFloor,
+		Formats:                     q.Formats,
+		Sizes:                       q.Sizes,
+		BlockedAdvertiserDomains:    q.BlockedAdvertiserDomains,
+		BlockedAdvertiserCategories: q.BlockedAdvertiserCategories,
+		DealIDs:                     q.DealIDs,
+		PrivateAuction:              q.PrivateAuction,
+		ExcludeHouse:                q.ExcludeHouse,
+		Candidates:                  candidates,
+		Excluded:                    excluded,
+		Scores:                      make([]model.AdRequestScore, len(ranked)),
+		Winners:                     make([]model.AdRequestWinner, len(ads)),
+	}
+	for i, item := range ranked {
+		request.Scores[i] = model.AdRequestScore{
+			LineItemID: item.ID,
+			Priority:   item.Priority,
+			Tier:       item.tier,
+			Score:      item.score,
+		}
+	}
+	for i, ad := range ads {
+		request.Winners[i] = model.AdRequestWinner{
+			LineItemID:   ad.ID,
+			AdvertiserID: ad.AdvertiserID,
+			DealID:       ad.DealID,
+			Bid:          ad.Bid,
+		}
+		if ad.Creative != nil {
+			request.Winners[i].CreativeID = ad.Creative.ID
+		}
+	}
+	return request
+}
+
+// AdQueryFromRequest returns the query of a logged ad request. The geo, device
+// and segments of the request are already resolved, so they are not looked up again.
+func AdQueryFromRequest(request *model.AdRequest) AdQuery {
+	segments := request.Segments
+	if segments == nil {
+		segments = []string{}
+	}
+	return AdQuery{
+		Placement:                   request.Placement,
+		Category:                    request.Category,
+		Keyword:                     request.Keyword,
+		Domain:                      request.Domain,
+		Limit:                       request.Limit,
+		Geo:                         request.Geo,
+		Device:                      request.Device,
+		Segments:                    segments,
+		BidFloor:                    request.BidFloor,
+		Formats:                     request.Formats,
+		Sizes:                       request.Sizes,
+		BlockedAdvertiserDomains:    request.BlockedAdvertiserDomains,
+		BlockedAdvertiserCategories: request.BlockedAdvertiserCategories,
+		DealIDs:                     request.DealIDs,
+		PrivateAuction:              request.PrivateAuction,
+		ExcludeHouse:                request.ExcludeHouse,
+	}
+}
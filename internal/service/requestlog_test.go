@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+type recordingRequestSink struct {
+	requests []*model.AdRequest
+}
+
+func (s *recordingRequestSink) Publish(request *model.AdRequest) error {
+	s.requests = append(s.requests, request)
+	return nil
+}
+
+func TestAdService_GetWinningAds_LogsRequest(t *testing.T) {
+	s, r := newTestAdService()
+	requestSink := &recordingRequestSink{}
+	s.requests = NewAdRequestLog(r.inventory, requestSink, 0.5, zap.NewNop().Sugar())
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_high", AdvertiserID: "adv_1", Bid: 5, Budget: 100, Placement: "top", Status: model.LineItemStatusActive, Keywords: []string{"shoes"}})
+	addServableLineItem(r, &model.LineItem{ID: "li_low", AdvertiserID: "adv_1", Bid: 1, Budget: 100, Placement: "top", Status: model.LineItemStatusActive, Keywords: []string{"shoes"}})
+	addServableLineItem(r, &model.LineItem{ID: "li_other", AdvertiserID: "adv_1", Bid: 9, Budget: 100, Placement: "top", Status: model.LineItemStatusActive, Keywords: []string{"hats"}})
+	q := AdQuery{Placement: "top", Keyword: "shoes", Device: model.Device{Type: model.DeviceTypeMobile}, Limit: 1}
+
+	s.requests.randFloat = func() float64 { return 0.7 }
+	if _, err := s.GetWinningAds(q); err != nil {
+		t.Fatalf("GetWinningAds() error = %v", err)
+	}
+	s.requests.randFloat = func() float64 { return 0.3 }
+	if _, err := s.GetWinningAds(q); err != nil {
+		t.Fatalf("GetWinningAds() error = %v", err)
+	}
+
+	if len(requestSink.requests) != 1 {
+		t.Fatalf("published %d requests, want the sampled request", len(requestSink.requests))
+	}
+	counted, _ := r.inventory.GetInventory(repo.GetInventoryFilter{Placement: "top"})
+	if len(counted) != 1 || counted[0].Keyword != "shoes" || counted[0].Requests != 2 {
+		t.Errorf("inventory = %+v, want the sampled request counted as 2 requests", counted)
+	}
+	request := requestSink.requests[0]
+	if request.Keyword != "shoes" || request.Device.Type != model.DeviceTypeMobile || request.Limit != 1 || request.SampleRate != 0.5 || request.Weight() != 2 {
+		t.Errorf("request = %+v, want the request context sampled at 0.5", request)
+	}
+	if request.Candidates != 2 || request.Excluded[exclusionKeywordMismatch] != 1 {
+		t.Errorf("request candidates = %d, excluded = %v, want 2 candidates and 1 keyword mismatch", request.Candidates, request.Excluded)
+	}
+	if len(request.Scores) != 2 || request.Scores[0].LineItemID != "li_high" || request.Scores[0].Score != 6 || request.Scores[1].LineItemID != "li_low" {
+		t.Errorf("request scores = %+v, want li_high ahead of li_low", request.Scores)
+	}
+	if len(request.Winners) != 1 || request.Winners[0].LineItemID != "li_high" || request.Winners[0].Bid != 5 || request.Winners[0].CreativeID != "cr_li_high" {
+		t.Errorf("request winners = %+v, want li_high at its bid", request.Winners)
+	}
+}
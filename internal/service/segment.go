@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// SegmentService stores the audience segments of users for targeting
+type SegmentService struct {
+	repo       repo.SegmentRepository
+	defaultTTL time.Duration
+	now        func() time.Time
+	log        *zap.SugaredLogger
+}
+
+// NewSegmentService creates a new SegmentService, memberships uploaded without TTL expire after defaultTTL
+func NewSegmentService(repo repo.SegmentRepository, defaultTTL time.Duration, log *zap.SugaredLogger) *SegmentService {
+	return &SegmentService{
+		repo:       repo,
+		defaultTTL: defaultTTL,
+		now:        time.Now,
+		log:        log,
+	}
+}
+
+// Upload adds the memberships of a bulk upload, extending the expiry of existing memberships
+func (s *SegmentService) Upload(upload model.SegmentUpload) (*model.SegmentUploadResult, error) {
+	now := s.now()
+	users := make(map[string]struct{})
+	memberships := make([]model.SegmentMembership, 0, len(upload.Memberships))
+	for _, item := range upload.Memberships {
+		ttl := s.defaultTTL
+		if item.TTLSeconds > 0 {
+			ttl = time.Duration(item.TTLSeconds) * time.Second
+		}
+		for _, segmentID := range item.SegmentIDs {
+			memberships = append(memberships, model.SegmentMembership{
+				UserID:    item.UserID,
+				SegmentID: segmentID,
+				ExpiresAt: now.Add(ttl),
+			})
+		}
+		users[item.UserID] = struct{}{}
+	}
+	if err := s.AddMemberships(memberships); err != nil {
+		return nil, err
+	}
+	return &model.SegmentUploadResult{Users: len(users), Memberships: len(memberships)}, nil
+}
+
+// AddMemberships adds memberships with their own expiry
+func (s *SegmentService) AddMemberships(memberships []model.SegmentMembership) error {
+	if err := s.repo.AddMemberships(memberships); err != nil {
+		return err
+	}
+	s.log.Infow("Segment memberships added",
+		"memberships", len(memberships),
+	)
+	return nil
+}
+
+// RemoveMembership removes a user from a segment
+func (s *SegmentService) RemoveMembership(userID, segmentID string) error {
+	_, err := s.repo.RemoveMembership(userID, segmentID)
+	return err
+}
+
+// GetUserMemberships returns the unexpired memberships of a user
+func (s *SegmentService) GetUserMemberships(userID string) ([]model.SegmentMembership, error) {
+	return s.repo.GetUserSegments(userID, s.now())
+}
+
+// GetUserSegments returns the IDs of the segments the user currently belongs to
+func (s *SegmentService) GetUserSegments(userID string) ([]string, error) {
+	memberships, err := s.GetUserMemberships(userID)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]string, len(memberships))
+	for i, m := range memberships {
+		segments[i] = m.SegmentID
+	}
+	return segments, nil
+}
+
+// RunExpiry deletes expired memberships every interval until ctx is done
+func (s *SegmentService) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpired(s.now())
+			if err != nil {
+				s.log.Errorw("error in deleting expired segment memberships", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				s.log.Infow("Expired segment memberships deleted", "memberships", deleted)
+			}
+		}
+	}
+}
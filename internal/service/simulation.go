@@ -0,0 +1,205 @@
+package service
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+// Snapshot is the state of the accounts and line items ad requests are replayed against
+type Snapshot struct {
+	Advertisers []*model.Advertiser `json:"advertisers"`
+	Campaigns   []*model.Campaign   `json:"campaigns"`
+	Creatives   []*model.Creative   `json:"creatives"`
+	LineItems   []*model.LineItem   `json:"line_items"`
+	Deals       []*model.Deal       `json:"deals"`
+}
+
+// SimulationConfig is the ad selection configuration of a replay
+type SimulationConfig struct {
+	Separation SeparationRules
+	Weights    ScoringWeights
+	Floors     FloorRules
+	DealTiers  []model.DealPriceType
+	Pacing     PacingRules
+}
+
+// SimulationResult summarizes a replay. Requests, impressions, revenue and
+// spend are weighted by the sample rate of the logged requests.
+type SimulationResult struct {
+	Requests    float64 `json:"requests"`
+	Filled      float64 `json:"filled"`
+	Impressions float64 `json:"impressions"`
+	// Revenue is the sum of the clearing prices of the impressions
+	Revenue         float64            `json:"revenue"`
+	AdvertiserSpend map[string]float64 `json:"advertiser_spend"`
+	// Failed is the number of logged requests which failed to replay
+	Failed int `json:"failed"`
+}
+
+// FillRate returns the share of requests which returned at least one ad
+func (r *SimulationResult) FillRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return r.Filled / r.Requests
+}
+
+// simulationSeed seeds the random choices of replays, so replays of the same
+// log differ only by their configuration
+const simulationSeed = 1
+
+// Simulate replays the logged ad requests in timestamp order through the ad
+// selection of a fresh AdService over a copy of the snapshot. The clock of the
+// replay is the timestamp of the request. A sampled request stands for Weight
+// requests, so its ads spend their bid Weight times and only serve when the
+// line item budget affords it, and count as Weight impressions for pacing.
+func Simulate(snapshot *Snapshot, requests []*model.AdRequest, cfg SimulationConfig, log *zap.SugaredLogger) (*SimulationResult, error) {
+	r := newSimulationRepos(log)
+	if err := r.load(snapshot); err != nil {
+		return nil, err
+	}
+
+	var clock time.Time
+	now := func() time.Time { return clock }
+	rng := rand.New(rand.NewPCG(simulationSeed, simulationSeed))
+
+	advertiserService := NewAdvertiserService(r.advertisers, log)
+	campaignService := NewCampaignService(r.campaigns, advertiserService, log)
+	creativeService := NewCreativeService(r.creatives, advertiserService, CreativePolicy{}, log)
+	creativeService.randFloat = rng.Float64
+	segmentService := NewSegmentService(repo.NewSegmentRepository(log), 0, log)
+	audienceService := NewAudienceService(repo.NewAudienceRepository(log), r.tracking, r.lineItems, advertiserService, segmentService, log)
+	dealService := NewDealService(r.deals, cfg.DealTiers, log)
+	lineItemService := NewLineItemService(r.lineItems, advertiserService, campaignService, creativeService, audienceService, dealService, log)
+	lineItemService.now = now
+	deliveryService := NewDeliveryService(r.lineItems, r.tracking, cfg.Pacing, nil, log)
+	deliveryService.now = now
+	deliveryService.randFloat = rng.Float64
+	// replayed requests are not logged again
+	requestLog := NewAdRequestLog(repo.NewInventoryRepository(log), nil, 0, log)
+	adService := NewAdService(AdServiceDeps{
+		LineItemRepo:    r.lineItems,
+		LineItemService: lineItemService,
+		AdvertiserRepo:  r.advertisers,
+		CampaignRepo:    r.campaigns,
+		CreativeService: creativeService,
+		Segments:        segmentService,
+		Deals:           dealService,
+		Delivery:        deliveryService,
+		Requests:        requestLog,
+	}, AdSelectionRules{
+		Separation: cfg.Separation,
+		Weights:    cfg.Weights,
+		Floors:     cfg.Floors,
+	}, log)
+	adService.now = now
+
+	ordered := make([]*model.AdRequest, len(requests))
+	copy(ordered, requests)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+
+	result := &SimulationResult{AdvertiserSpend: make(map[string]float64)}
+	for _, request := range ordered {
+		clock = request.Timestamp
+		weight := request.Weight()
+		ads, err := adService.selectAds(AdQueryFromRequest(request), func(lineItem *model.LineItem) (*model.LineItem, bool) {
+			amount := lineItem.Bid * weight
+			if lineItem.Budget-lineItem.Reserved < amount {
+				return nil, false
+			}
+			return adService.chargeBudgets(lineItem, amount, 0)
+		})
+		if err != nil {
+			log.Warnw("error in replaying ad request",
+				"id", request.ID,
+				"error", err)
+			result.Failed++
+			continue
+		}
+		result.Requests += weight
+		if len(ads) > 0 {
+			result.Filled += weight
+		}
+		for _, ad := range ads {
+			result.Impressions += weight
+			result.Revenue += ad.Bid * weight
+			result.AdvertiserSpend[ad.AdvertiserID] += ad.Bid * weight
+			impression := &model.TrackingEvent{
+				EventType:  model.TrackingEventTypeImpression,
+				LineItemID: ad.ID,
+				Placement:  ad.Placement,
+				Timestamp:  request.Timestamp,
+			}
+			for range int(math.Round(weight)) {
+				_ = r.tracking.IncrementRollups(impression, model.RollupGranularityMinute, model.RollupGranularityHour)
+				deliveryService.OnTrackingEvent(impression)
+			}
+		}
+	}
+	return result, nil
+}
+
+// simulationRepos are the repositories of a replay
+type simulationRepos struct {
+	advertisers repo.AdvertiserRepository
+	campaigns   repo.CampaignRepository
+	creatives   repo.CreativeRepository
+	lineItems   repo.LineItemRepository
+	deals       repo.DealRepository
+	tracking    repo.TrackingEventRepository
+}
+
+func newSimulationRepos(log *zap.SugaredLogger) simulationRepos {
+	return simulationRepos{
+		advertisers: repo.NewAdvertiserRepository(log),
+		campaigns:   repo.NewCampaignRepository(log),
+		creatives:   repo.NewCreativeRepository(log),
+		lineItems:   repo.NewLineItemRepository(log),
+		deals:       repo.NewDealRepository(log),
+		tracking:    repo.NewTrackingEventRepository(log),
+	}
+}
+
+// load stores copies of the snapshot, so replays do not spend its budgets
+func (r simulationRepos) load(snapshot *Snapshot) error {
+	for _, advertiser := range snapshot.Advertisers {
+		advertiser := *advertiser
+		if err := r.advertisers.CreateAdvertiser(&advertiser); err != nil {
+			return err
+		}
+	}
+	for _, campaign := range snapshot.Campaigns {
+		campaign := *campaign
+		if err := r.campaigns.CreateCampaign(&campaign); err != nil {
+			return err
+		}
+	}
+	for _, creative := range snapshot.Creatives {
+		creative := *creative
+		if err := r.creatives.CreateCreative(&creative); err != nil {
+			return err
+		}
+	}
+	for _, lineItem := range snapshot.LineItems {
+		lineItem := *lineItem
+		if err := r.lineItems.CreateLineItem(&lineItem); err != nil {
+			return err
+		}
+	}
+	for _, deal := range snapshot.Deals {
+		deal := *deal
+		if err := r.deals.CreateDeal(&deal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
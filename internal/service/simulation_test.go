@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+)
+
+func TestSimulate(t *testing.T) {
+	snapshot := &Snapshot{
+		Advertisers: []*model.Advertiser{
+			{ID: "adv_a", Status: model.AdvertiserStatusActive},
+			{ID: "adv_b", Status: model.AdvertiserStatusActive},
+		},
+		Creatives: []*model.Creative{
+			{ID: "cr_a", AdvertiserID: "adv_a", Status: model.CreativeStatusApproved},
+			{ID: "cr_b", AdvertiserID: "adv_b", Status: model.CreativeStatusApproved},
+		},
+		LineItems: []*model.LineItem{
+			{ID: "li_top", AdvertiserID: "adv_a", Bid: 2, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+				Creatives: []model.LineItemCreative{{CreativeID: "cr_a", Weight: 1}}},
+			{ID: "li_top_low", AdvertiserID: "adv_b", Bid: 1, Budget: 100, Placement: "top", Status: model.LineItemStatusActive,
+				Creatives: []model.LineItemCreative{{CreativeID: "cr_b", Weight: 1}}},
+			{ID: "li_side", AdvertiserID: "adv_b", Bid: 1, Budget: 100, Placement: "side", Status: model.LineItemStatusActive,
+				Creatives: []model.LineItemCreative{{CreativeID: "cr_b", Weight: 1}}},
+		},
+	}
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	var requests []*model.AdRequest
+	for i, placement := range []string{"top", "side", "top", "side"} {
+		requests = append(requests, &model.AdRequest{ID: placement, Timestamp: start.Add(time.Duration(i) * time.Minute), Placement: placement, Limit: 1, SampleRate: 0.5})
+	}
+	log := zap.NewNop().Sugar()
+
+	base, err := Simulate(snapshot, requests, SimulationConfig{Weights: DefaultScoringWeights}, log)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if base.Requests != 8 || base.FillRate() != 1 || base.Impressions != 8 || base.Revenue != 12 {
+		t.Errorf("Simulate() = %+v, want 8 filled requests and 12 revenue", base)
+	}
+	if base.AdvertiserSpend["adv_a"] != 8 || base.AdvertiserSpend["adv_b"] != 4 {
+		t.Errorf("Simulate() spend = %v, want adv_a 8 and adv_b 4", base.AdvertiserSpend)
+	}
+
+	floored, err := Simulate(snapshot, requests, SimulationConfig{Weights: DefaultScoringWeights, Floors: FloorRules{Default: 1.5}}, log)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if floored.FillRate() != 0.5 || floored.Revenue != 8 || floored.AdvertiserSpend["adv_b"] != 0 {
+		t.Errorf("Simulate() with floor = %+v, want side requests unfilled", floored)
+	}
+
+	// each top request spends 4 of li_top, whose budget only affords the first
+	snapshot.LineItems[0].Budget = 6
+	limited, err := Simulate(snapshot, requests, SimulationConfig{Weights: DefaultScoringWeights}, log)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if limited.Revenue != 10 || limited.AdvertiserSpend["adv_a"] != 4 || limited.AdvertiserSpend["adv_b"] != 6 {
+		t.Errorf("Simulate() with limited budget = %+v, want the second top request served by li_top_low", limited)
+	}
+
+	if snapshot.LineItems[0].Budget != 6 {
+		t.Errorf("snapshot budget = %v, want replays not to spend it", snapshot.LineItems[0].Budget)
+	}
+}
@@ -1,37 +1,228 @@
 package service
 
 import (
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"sweng-task/internal/domain_errors"
 	"sweng-task/internal/model"
 	"sweng-task/internal/repo"
+	"sweng-task/internal/sink"
 )
 
+// TrackingEventListener is notified about every valid tracking event after it is persisted
+type TrackingEventListener interface {
+	OnTrackingEvent(event *model.TrackingEvent)
+}
+
+// ImpressionSettler settles the spend of served ads with their impression
+// events, valid or not
+type ImpressionSettler interface {
+	SettleImpression(event *model.TrackingEvent)
+}
+
 type TrackingService struct {
-	repo repo.TrackingEventRepository
-	log  *zap.SugaredLogger
+	repo        repo.TrackingEventRepository
+	attribution *AttributionService
+	ivt         *InvalidTrafficFilter
+	sinks       *sink.FanOutSink
+	settler     ImpressionSettler
+	listeners   []TrackingEventListener
+	log         *zap.SugaredLogger
 }
 
-func NewTrackingService(repo repo.TrackingEventRepository, log *zap.SugaredLogger) *TrackingService {
+// NewTrackingService creates a new TrackingService, settler may be nil when
+// the spend of invalid impressions is not refunded
+func NewTrackingService(repo repo.TrackingEventRepository, attribution *AttributionService, ivt *InvalidTrafficFilter, sinks *sink.FanOutSink, settler ImpressionSettler, log *zap.SugaredLogger) *TrackingService {
 	return &TrackingService{
-		repo: repo,
-		log:  log,
+		repo:        repo,
+		attribution: attribution,
+		ivt:         ivt,
+		sinks:       sinks,
+		settler:     settler,
+		log:         log,
 	}
 }
 
-// Track is uses repository level to persist event, and logs it.
-// Suggestion: We can add async to this stage, and let TrackingService.Track to be producer,
-// while consumers will persist events.
+// Subscribe registers a listener for valid tracking events.
+// Listeners must be registered before the service starts tracking.
+func (s *TrackingService) Subscribe(listener TrackingEventListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// Track is uses repository level to persist event, logs it, and publishes it to the configured sinks.
+// Events flagged by the invalid traffic filter are persisted with their reason code,
+// but do not count towards rollups and are not passed to listeners, and the spend
+// of flagged impressions is refunded. Valid impressions are charged the spend
+// of the served ad they settle.
+// Sinks are published to in the background. Dropped events and delivery failures
+// are recorded in sink metrics and do not fail the request, since the event is
+// already persisted.
 func (s *TrackingService) Track(event *model.TrackingEvent) error {
 	// Future: Budget consumption logic should be added here
+	if event.ID == "" {
+		event.ID = "ev_" + uuid.New().String()
+	}
+	// the cost is only set by the server
+	event.Cost = 0
+	if s.ivt != nil {
+		reason, err := s.ivt.Inspect(event)
+		if err != nil {
+			return err
+		}
+		event.InvalidReason = reason
+	}
+	if event.EventType == model.TrackingEventTypeConversion && s.attribution != nil {
+		if err := s.attribution.Attribute(event); err != nil {
+			return err
+		}
+	}
+	if s.settler != nil {
+		s.settler.SettleImpression(event)
+	}
+	return s.store(event)
+}
+
+// RecordImpression stores an impression the server charged itself, such as
+// the win of an external auction, whose ad has no impression tracker. It is
+// neither inspected for invalid traffic nor settled against served ads.
+func (s *TrackingService) RecordImpression(event *model.TrackingEvent) error {
+	if event.ID == "" {
+		event.ID = "ev_" + uuid.New().String()
+	}
+	return s.store(event)
+}
+
+// store persists the event, counts it in the rollups of its line item and
+// notifies the listeners when it is valid, and publishes it to the sinks
+func (s *TrackingService) store(event *model.TrackingEvent) error {
 	err := s.repo.CreateTrackingEvent(event)
 	if err != nil {
 		return err
 	}
+	if event.IsValid() {
+		if event.LineItemID != "" {
+			err = s.repo.IncrementRollups(event, model.RollupGranularityMinute, model.RollupGranularityHour)
+			if err != nil {
+				return err
+			}
+		}
+		for _, listener := range s.listeners {
+			listener.OnTrackingEvent(event)
+		}
+	}
 	s.log.Infow("tracking event stored",
 		"type", event.EventType,
 		"line_item", event.LineItemID,
 		"placement", event.Placement,
+		"attribution", event.Attribution,
+		"invalid_reason", event.InvalidReason,
 	)
+	if s.sinks != nil {
+		_ = s.sinks.Publish(event)
+	}
 	return nil
 }
+
+// SinkStats returns delivery metrics of the configured event sinks
+func (s *TrackingService) SinkStats() []sink.Stats {
+	if s.sinks == nil {
+		return []sink.Stats{}
+	}
+	return s.sinks.Stats()
+}
+
+type TrackingEventQuery struct {
+	LineItemID string
+	EventType  model.TrackingEventType
+	Placement  string
+	UserID     string
+	From       time.Time
+	To         time.Time
+	Cursor     string
+	Limit      int
+}
+
+// Query returns a page of stored tracking events matching q
+func (s *TrackingService) Query(q TrackingEventQuery) (*model.TrackingEventPage, error) {
+	offset, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	events, next, err := s.repo.GetTrackingEvents(repo.GetTrackingEventsFilter{
+		LineItemID: q.LineItemID,
+		EventType:  q.EventType,
+		Placement:  q.Placement,
+		UserID:     q.UserID,
+		From:       q.From,
+		To:         q.To,
+		Offset:     offset,
+		Limit:      q.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	page := &model.TrackingEventPage{Events: events}
+	if next > 0 {
+		page.NextCursor = encodeCursor(next)
+	}
+	return page, nil
+}
+
+type LineItemStatsQuery struct {
+	LineItemID  string
+	Placement   string
+	Granularity model.RollupGranularity
+	From        time.Time
+	To          time.Time
+}
+
+// Stats returns counts of a line item from the rollup counters maintained by Track
+func (s *TrackingService) Stats(q LineItemStatsQuery) (*model.LineItemStats, error) {
+	rollups, err := s.repo.GetRollups(repo.GetRollupsFilter{
+		LineItemID:  q.LineItemID,
+		Placement:   q.Placement,
+		Granularity: q.Granularity,
+		From:        q.From,
+		To:          q.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats := &model.LineItemStats{
+		LineItemID:  q.LineItemID,
+		Granularity: q.Granularity,
+		Series:      rollups,
+	}
+	for _, rollup := range rollups {
+		stats.Impressions += rollup.Impressions
+		stats.Clicks += rollup.Clicks
+		stats.Conversions += rollup.Conversions
+		stats.VideoCounts.Merge(rollup.VideoCounts)
+	}
+	return stats, nil
+}
+
+// Cursors are opaque to clients; they wrap the repository offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, domain_errors.ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, domain_errors.ErrInvalidCursor
+	}
+	return offset, nil
+}
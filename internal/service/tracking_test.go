@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/domain_errors"
+	"sweng-task/internal/model"
+	"sweng-task/internal/repo"
+)
+
+func TestTrackingService_Query(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	s := NewTrackingService(repo.NewTrackingEventRepository(log), nil, nil, nil, nil, log)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		for _, lineItemID := range []string{"li_1", "li_2"} {
+			err := s.Track(&model.TrackingEvent{
+				EventType:  model.TrackingEventTypeImpression,
+				LineItemID: lineItemID,
+				Timestamp:  base.Add(time.Duration(i) * time.Hour),
+			})
+			if err != nil {
+				t.Fatalf("Track() error = %v", err)
+			}
+		}
+	}
+
+	var got []*model.TrackingEvent
+	q := TrackingEventQuery{LineItemID: "li_1", From: base.Add(time.Hour), Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("pagination did not terminate")
+		}
+		page, err := s.Query(q)
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		got = append(got, page.Events...)
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	if len(got) != 4 {
+		t.Fatalf("Query() returned %d events, want 4", len(got))
+	}
+	for i, event := range got {
+		if event.LineItemID != "li_1" || !event.Timestamp.Equal(base.Add(time.Duration(i+1)*time.Hour)) {
+			t.Errorf("event %d = %s at %s, unexpected", i, event.LineItemID, event.Timestamp)
+		}
+		if event.ID == "" {
+			t.Errorf("event %d has no ID", i)
+		}
+	}
+
+	if _, err := s.Query(TrackingEventQuery{Cursor: "not a cursor"}); !errors.Is(err, domain_errors.ErrInvalidCursor) {
+		t.Errorf("Query() with invalid cursor error = %v, want %v", err, domain_errors.ErrInvalidCursor)
+	}
+}
+
+func TestTrackingService_Stats(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	s := NewTrackingService(repo.NewTrackingEventRepository(log), nil, nil, nil, nil, log)
+
+	base := time.Date(2025, 1, 1, 10, 0, 30, 0, time.UTC)
+	for _, e := range []struct {
+		eventType model.TrackingEventType
+		placement string
+		offset    time.Duration
+	}{
+		{model.TrackingEventTypeImpression, "top", 0},
+		{model.TrackingEventTypeImpression, "top", 10 * time.Second},
+		{model.TrackingEventTypeClick, "top", 20 * time.Second},
+		{model.TrackingEventTypeImpression, "top", 2 * time.Minute},
+		{model.TrackingEventTypeImpression, "side", 3 * time.Minute},
+	} {
+		_ = s.Track(&model.TrackingEvent{EventType: e.eventType, LineItemID: "li_1", Placement: e.placement, Timestamp: base.Add(e.offset)})
+	}
+
+	tests := []struct {
+		name            string
+		q               LineItemStatsQuery
+		wantImpressions int64
+		wantClicks      int64
+		wantSeries      int
+	}{
+		{"hourly", LineItemStatsQuery{LineItemID: "li_1", Granularity: model.RollupGranularityHour}, 4, 1, 2},
+		{"minutely", LineItemStatsQuery{LineItemID: "li_1", Granularity: model.RollupGranularityMinute}, 4, 1, 3},
+		{"by placement", LineItemStatsQuery{LineItemID: "li_1", Placement: "top", Granularity: model.RollupGranularityMinute, From: base.Add(time.Minute)}, 1, 0, 1},
+		{"unknown line item", LineItemStatsQuery{LineItemID: "li_2", Granularity: model.RollupGranularityHour}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Stats(tt.q)
+			if err != nil {
+				t.Fatalf("Stats() error = %v", err)
+			}
+			if got.Impressions != tt.wantImpressions || got.Clicks != tt.wantClicks || len(got.Series) != tt.wantSeries {
+				t.Errorf("Stats() = %d impressions, %d clicks, %d series; want %d, %d, %d",
+					got.Impressions, got.Clicks, len(got.Series), tt.wantImpressions, tt.wantClicks, tt.wantSeries)
+			}
+		})
+	}
+}
+
+func TestTrackingService_RefundsInvalidImpressions(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	ads, r := newTestAdService()
+	ivt := NewInvalidTrafficFilter(r.tracking, InvalidTrafficRules{BotUserAgents: []string{"bot"}}, log)
+	s := NewTrackingService(r.tracking, nil, ivt, nil, ads, log)
+	_ = r.advertisers.CreateAdvertiser(&model.Advertiser{ID: "adv_1", Status: model.AdvertiserStatusActive})
+	addServableLineItem(r, &model.LineItem{ID: "li_1", AdvertiserID: "adv_1", Bid: 2, Budget: 100, Placement: "top", Status: model.LineItemStatusActive})
+	assertServed(t, ads, AdQuery{Placement: "top", Limit: 1}, "li_1", "li_1")
+
+	track := func(userAgent string) {
+		t.Helper()
+		if err := s.Track(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: "li_1", UserAgent: userAgent, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Track() error = %v", err)
+		}
+	}
+	budget := func() (float64, float64) {
+		lineItem, _ := r.lineItems.GetLineItemById("li_1")
+		advertiser, _ := r.advertisers.GetAdvertiserById("adv_1")
+		return lineItem.Budget, advertiser.Spent
+	}
+
+	track("Mozilla/5.0")
+	track("crawlerbot/1.0")
+	if lineItemBudget, spent := budget(); lineItemBudget != 98 || spent != 2 {
+		t.Errorf("budget = %v, spent = %v after one valid and one invalid impression, want 98 and 2", lineItemBudget, spent)
+	}
+	// invalid impressions beyond the served ads are not refunded
+	track("crawlerbot/1.0")
+	if lineItemBudget, spent := budget(); lineItemBudget != 98 || spent != 2 {
+		t.Errorf("budget = %v, spent = %v after unserved invalid impression, want 98 and 2", lineItemBudget, spent)
+	}
+}
@@ -0,0 +1,48 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/config"
+)
+
+// NewFromConfig creates a FanOutSink over every sink type enabled in cfg
+func NewFromConfig(cfg config.SinkConfig, log *zap.SugaredLogger) (*FanOutSink, error) {
+	var sinks []EventSink
+	for _, t := range cfg.Types {
+		var (
+			s   EventSink
+			err error
+		)
+		switch strings.TrimSpace(t) {
+		case TypeStdout:
+			s = NewStdoutSink()
+		case TypeFile:
+			s, err = NewFileSink(cfg.FilePath)
+		case TypeRotating:
+			s, err = NewRotatingFileSink(cfg.RotatePath, cfg.RotateMaxBytes, cfg.RotateMaxAge)
+		case TypeKafka:
+			s = NewKafkaSink(NewKafkaProducer(KafkaProducerConfig{
+				Broker:    cfg.KafkaBroker,
+				Topic:     cfg.KafkaTopic,
+				Partition: cfg.KafkaPartition,
+				Acks:      cfg.KafkaAcks,
+				Timeout:   cfg.KafkaTimeout,
+			}))
+		default:
+			err = fmt.Errorf("unknown sink type %q", t)
+		}
+		if err != nil {
+			for _, opened := range sinks {
+				err = errors.Join(err, opened.Close())
+			}
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return NewFanOutSink(log, cfg.QueueSize, sinks...), nil
+}
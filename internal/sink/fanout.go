@@ -0,0 +1,131 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+)
+
+// Stats contains delivery metrics of a single sink
+type Stats struct {
+	Sink          string    `json:"sink"`
+	Delivered     int64     `json:"delivered"`
+	Failed        int64     `json:"failed"`
+	Dropped       int64     `json:"dropped"`
+	Queued        int       `json:"queued"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time,omitempty"`
+}
+
+type metrics struct {
+	delivered     atomic.Int64
+	failed        atomic.Int64
+	lastError     string
+	lastErrorTime time.Time
+	mu            sync.Mutex
+}
+
+func (m *metrics) recordFailure(err error) {
+	m.failed.Add(1)
+	m.mu.Lock()
+	m.lastError = err.Error()
+	m.lastErrorTime = time.Now()
+	m.mu.Unlock()
+}
+
+var _ EventSink = (*FanOutSink)(nil)
+
+// FanOutSink publishes every event to all of its sinks and keeps delivery
+// metrics per sink. Every sink has its own bounded queue drained in the
+// background, so a slow or failing sink neither blocks the publisher nor
+// delays delivery to the others. Events are dropped when a queue is full.
+type FanOutSink struct {
+	sinks   []EventSink
+	queues  []*queue[*model.TrackingEvent]
+	metrics []*metrics
+	log     *zap.SugaredLogger
+}
+
+// NewFanOutSink creates a FanOutSink queueing up to queueSize events per sink
+func NewFanOutSink(log *zap.SugaredLogger, queueSize int, sinks ...EventSink) *FanOutSink {
+	s := &FanOutSink{
+		sinks:   sinks,
+		queues:  make([]*queue[*model.TrackingEvent], len(sinks)),
+		metrics: make([]*metrics, len(sinks)),
+		log:     log,
+	}
+	for i := range sinks {
+		s.metrics[i] = &metrics{}
+		s.queues[i] = newQueue(queueSize, func(event *model.TrackingEvent) {
+			s.deliver(i, event)
+		})
+	}
+	return s
+}
+
+func (s *FanOutSink) Name() string {
+	return "fanout"
+}
+
+// Publish queues the event for every sink without waiting for delivery, and
+// returns ErrQueueFull when a sink dropped it. Delivery failures are only
+// recorded in the sink metrics.
+func (s *FanOutSink) Publish(event *model.TrackingEvent) error {
+	var errs []error
+	for i, q := range s.queues {
+		if err := q.push(event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.sinks[i].Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *FanOutSink) deliver(i int, event *model.TrackingEvent) {
+	sink := s.sinks[i]
+	if err := sink.Publish(event); err != nil {
+		s.metrics[i].recordFailure(err)
+		s.log.Warnw("tracking event delivery failed",
+			"sink", sink.Name(),
+			"line_item", event.LineItemID,
+			"error", err,
+		)
+		return
+	}
+	s.metrics[i].delivered.Add(1)
+}
+
+// Stats returns delivery metrics of every sink, in configuration order
+func (s *FanOutSink) Stats() []Stats {
+	result := make([]Stats, len(s.sinks))
+	for i, sink := range s.sinks {
+		m := s.metrics[i]
+		m.mu.Lock()
+		result[i] = Stats{
+			Sink:          sink.Name(),
+			Delivered:     m.delivered.Load(),
+			Failed:        m.failed.Load(),
+			Dropped:       s.queues[i].dropped.Load(),
+			Queued:        s.queues[i].len(),
+			LastError:     m.lastError,
+			LastErrorTime: m.lastErrorTime,
+		}
+		m.mu.Unlock()
+	}
+	return result
+}
+
+// Close flushes the queued events to the sinks and closes them
+func (s *FanOutSink) Close() error {
+	var errs []error
+	for i, sink := range s.sinks {
+		s.queues[i].close()
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
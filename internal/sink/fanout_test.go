@@ -0,0 +1,114 @@
+package sink
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+)
+
+// recordingSink records published events, or fails every publish with err
+type recordingSink struct {
+	name   string
+	err    error
+	mu     sync.Mutex
+	events []*model.TrackingEvent
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Publish(event *model.TrackingEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+// blockingSink blocks every publish until release is closed
+type blockingSink struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *blockingSink) Name() string { return "blocking" }
+
+func (s *blockingSink) Publish(*model.TrackingEvent) error {
+	s.once.Do(func() { close(s.started) })
+	<-s.release
+	return nil
+}
+
+func (s *blockingSink) Close() error { return nil }
+
+func TestFanOutSink_FailingSink(t *testing.T) {
+	failing := &recordingSink{name: "failing", err: errors.New("broker unavailable")}
+	healthy := &recordingSink{name: "healthy"}
+	s := NewFanOutSink(zap.NewNop().Sugar(), 10, failing, healthy)
+
+	events := []*model.TrackingEvent{
+		{EventType: model.TrackingEventTypeImpression, LineItemID: "li_1"},
+		{EventType: model.TrackingEventTypeClick, LineItemID: "li_1"},
+	}
+	for _, event := range events {
+		if err := s.Publish(event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(healthy.events) != len(events) {
+		t.Errorf("healthy sink received %d events, want %d", len(healthy.events), len(events))
+	}
+	stats := s.Stats()
+	if stats[0].Failed != 2 || stats[0].Delivered != 0 || stats[0].LastError != "broker unavailable" {
+		t.Errorf("failing sink stats = %+v, want 2 failed with last error", stats[0])
+	}
+	if stats[1].Failed != 0 || stats[1].Delivered != 2 {
+		t.Errorf("healthy sink stats = %+v, want 2 delivered", stats[1])
+	}
+}
+
+func TestFanOutSink_DropsWhenQueueFull(t *testing.T) {
+	slow := &blockingSink{started: make(chan struct{}), release: make(chan struct{})}
+	healthy := &recordingSink{name: "healthy"}
+	s := NewFanOutSink(zap.NewNop().Sugar(), 1, slow, healthy)
+	event := &model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: "li_1"}
+
+	if err := s.Publish(event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	<-slow.started
+	// the slow sink is delivering the first event and queues the second
+	if err := s.Publish(event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := s.Publish(event); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Publish() error = %v, want %v", err, ErrQueueFull)
+	}
+	if got := s.Stats()[0]; got.Dropped != 1 || got.Queued != 1 {
+		t.Errorf("slow sink stats = %+v, want 1 dropped and 1 queued", got)
+	}
+
+	close(slow.release)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	stats := s.Stats()
+	if stats[0].Delivered != 2 || stats[0].Queued != 0 {
+		t.Errorf("slow sink stats after Close() = %+v, want the queue flushed", stats[0])
+	}
+	if len(healthy.events) != 3 {
+		t.Errorf("healthy sink received %d events, want 3", len(healthy.events))
+	}
+}
@@ -0,0 +1,307 @@
+package sink
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"sweng-task/internal/model"
+)
+
+// Kafka wire protocol constants used by the producer
+const (
+	kafkaAPIKeyProduce     int16 = 0
+	kafkaProduceVersion    int16 = 3
+	kafkaRecordBatchMagic  int8  = 2
+	kafkaDefaultClientID         = "ad-bidding-service"
+	kafkaMaxResponseLength       = 1 << 20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// KafkaProducerConfig configures a KafkaProducer
+type KafkaProducerConfig struct {
+	// Broker is the host:port of the broker leading Partition of Topic.
+	// Leader discovery through metadata requests is not performed.
+	Broker    string
+	Topic     string
+	Partition int32
+	ClientID  string
+	// Acks is the number of acknowledgements required: 0, 1 or -1 (all)
+	Acks    int16
+	Timeout time.Duration
+}
+
+// KafkaProducer is a minimal synchronous producer speaking the Kafka
+// binary protocol (Produce v3, record batch v2). It keeps a single
+// connection to the broker and reconnects after failures.
+type KafkaProducer struct {
+	cfg           KafkaProducerConfig
+	conn          net.Conn
+	correlationID int32
+	mu            sync.Mutex
+}
+
+// KafkaError is returned when the broker rejects a produce request
+type KafkaError struct {
+	Code int16
+}
+
+func (e *KafkaError) Error() string {
+	return fmt.Sprintf("kafka broker returned error code %d", e.Code)
+}
+
+func NewKafkaProducer(cfg KafkaProducerConfig) *KafkaProducer {
+	if cfg.ClientID == "" {
+		cfg.ClientID = kafkaDefaultClientID
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &KafkaProducer{cfg: cfg}
+}
+
+// Produce sends the given values as a single record batch and waits for the
+// broker acknowledgement (unless Acks is 0).
+func (p *KafkaProducer) Produce(key []byte, values ...[]byte) error {
+	if len(values) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		conn, err := net.DialTimeout("tcp", p.cfg.Broker, p.cfg.Timeout)
+		if err != nil {
+			return err
+		}
+		p.conn = conn
+	}
+	p.correlationID++
+	err := p.roundTrip(p.correlationID, encodeRecordBatch(time.Now(), key, values))
+	if err != nil {
+		var kerr *KafkaError
+		if !errors.As(err, &kerr) {
+			// The connection state is unknown after an I/O error
+			p.conn.Close()
+			p.conn = nil
+		}
+	}
+	return err
+}
+
+func (p *KafkaProducer) roundTrip(correlationID int32, batch []byte) error {
+	if err := p.conn.SetDeadline(time.Now().Add(p.cfg.Timeout)); err != nil {
+		return err
+	}
+
+	var body kafkaEncoder
+	body.int16(kafkaAPIKeyProduce)
+	body.int16(kafkaProduceVersion)
+	body.int32(correlationID)
+	body.string(p.cfg.ClientID)
+	body.int16(-1) // null transactional id
+	body.int16(p.cfg.Acks)
+	body.int32(int32(p.cfg.Timeout / time.Millisecond))
+	body.int32(1) // topics
+	body.string(p.cfg.Topic)
+	body.int32(1) // partitions
+	body.int32(p.cfg.Partition)
+	body.bytes(batch)
+
+	var frame kafkaEncoder
+	frame.bytes(body.buf)
+	if _, err := p.conn.Write(frame.buf); err != nil {
+		return err
+	}
+	if p.cfg.Acks == 0 {
+		return nil
+	}
+	return readProduceResponse(p.conn, correlationID)
+}
+
+func readProduceResponse(r io.Reader, correlationID int32) error {
+	var size int32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return err
+	}
+	if size < 4 || size > kafkaMaxResponseLength {
+		return fmt.Errorf("invalid kafka response size %d", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+
+	d := kafkaDecoder{buf: buf}
+	if got := d.int32(); got != correlationID {
+		return fmt.Errorf("unexpected kafka correlation id %d, want %d", got, correlationID)
+	}
+	for topics := d.int32(); topics > 0; topics-- {
+		d.string()
+		for partitions := d.int32(); partitions > 0; partitions-- {
+			d.int32() // partition
+			code := d.int16()
+			d.int64() // base offset
+			d.int64() // log append time
+			if d.err == nil && code != 0 {
+				return &KafkaError{Code: code}
+			}
+		}
+	}
+	return d.err
+}
+
+func (p *KafkaProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// encodeRecordBatch encodes values as an uncompressed v2 record batch
+func encodeRecordBatch(ts time.Time, key []byte, values [][]byte) []byte {
+	var records kafkaEncoder
+	for i, value := range values {
+		var rec kafkaEncoder
+		rec.int8(0)   // attributes
+		rec.varint(0) // timestamp delta
+		rec.varint(int64(i))
+		if key == nil {
+			rec.varint(-1)
+		} else {
+			rec.varint(int64(len(key)))
+			rec.raw(key)
+		}
+		rec.varint(int64(len(value)))
+		rec.raw(value)
+		rec.varint(0) // headers
+
+		records.varint(int64(len(rec.buf)))
+		records.raw(rec.buf)
+	}
+
+	millis := ts.UnixMilli()
+	var tail kafkaEncoder // everything covered by the CRC
+	tail.int16(0)         // attributes
+	tail.int32(int32(len(values) - 1))
+	tail.int64(millis)
+	tail.int64(millis)
+	tail.int64(-1) // producer id
+	tail.int16(-1) // producer epoch
+	tail.int32(-1) // base sequence
+	tail.int32(int32(len(values)))
+	tail.raw(records.buf)
+
+	var batch kafkaEncoder
+	batch.int64(0) // base offset
+	batch.int32(int32(4 + 1 + 4 + len(tail.buf)))
+	batch.int32(-1) // partition leader epoch
+	batch.int8(kafkaRecordBatchMagic)
+	batch.int32(int32(crc32.Checksum(tail.buf, castagnoli)))
+	batch.raw(tail.buf)
+	return batch.buf
+}
+
+type kafkaEncoder struct {
+	buf []byte
+}
+
+func (e *kafkaEncoder) int8(v int8)   { e.buf = append(e.buf, byte(v)) }
+func (e *kafkaEncoder) int16(v int16) { e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v)) }
+func (e *kafkaEncoder) int32(v int32) { e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v)) }
+func (e *kafkaEncoder) int64(v int64) { e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v)) }
+func (e *kafkaEncoder) varint(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+func (e *kafkaEncoder) raw(b []byte) { e.buf = append(e.buf, b...) }
+func (e *kafkaEncoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+func (e *kafkaEncoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+type kafkaDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = io.ErrUnexpectedEOF
+		return make([]byte, max(n, 0))
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *kafkaDecoder) int8() int8   { return int8(d.next(1)[0]) }
+func (d *kafkaDecoder) int16() int16 { return int16(binary.BigEndian.Uint16(d.next(2))) }
+func (d *kafkaDecoder) int32() int32 { return int32(binary.BigEndian.Uint32(d.next(4))) }
+func (d *kafkaDecoder) int64() int64 { return int64(binary.BigEndian.Uint64(d.next(8))) }
+func (d *kafkaDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+func (d *kafkaDecoder) bytes() []byte {
+	return d.next(int(d.int32()))
+}
+
+var _ EventSink = (*KafkaSink)(nil)
+
+// KafkaSink publishes JSON encoded events to a Kafka topic, keyed by line item ID
+type KafkaSink struct {
+	producer *KafkaProducer
+}
+
+func NewKafkaSink(producer *KafkaProducer) *KafkaSink {
+	return &KafkaSink{producer: producer}
+}
+
+func (s *KafkaSink) Name() string {
+	return TypeKafka
+}
+
+func (s *KafkaSink) Publish(event *model.TrackingEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.producer.Produce([]byte(event.LineItemID), value)
+}
+
+func (s *KafkaSink) Close() error {
+	return s.producer.Close()
+}
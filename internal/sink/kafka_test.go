@@ -0,0 +1,158 @@
+package sink
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"sweng-task/internal/model"
+)
+
+type producedRecord struct {
+	topic     string
+	partition int32
+	key       string
+	value     []byte
+}
+
+// standInBroker accepts produce requests on a local listener, decodes the
+// record batches and answers with errorCode for every partition.
+func standInBroker(t *testing.T, errorCode int16) (string, <-chan producedRecord) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	records := make(chan producedRecord, 16)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var size int32
+			if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+				return
+			}
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return
+			}
+			d := kafkaDecoder{buf: buf}
+			if d.int16() != kafkaAPIKeyProduce || d.int16() != kafkaProduceVersion {
+				t.Errorf("unexpected api key or version")
+				return
+			}
+			correlationID := d.int32()
+			d.string() // client id
+			d.int16()  // transactional id
+			d.int16()  // acks
+			d.int32()  // timeout
+			d.int32()  // topics
+			topic := d.string()
+			d.int32() // partitions
+			partition := d.int32()
+			batch := kafkaDecoder{buf: d.bytes()}
+			batch.int64() // base offset
+			batch.int32() // batch length
+			batch.int32() // leader epoch
+			if magic := batch.int8(); magic != kafkaRecordBatchMagic {
+				t.Errorf("unexpected magic %d", magic)
+			}
+			crc := uint32(batch.int32())
+			if got := crc32.Checksum(batch.buf, castagnoli); got != crc {
+				t.Errorf("crc mismatch: got %d, want %d", got, crc)
+			}
+			batch.next(2 + 4 + 8 + 8 + 8 + 2 + 4)
+			for n := batch.int32(); n > 0; n-- {
+				batch.varint() // length
+				batch.int8()   // attributes
+				batch.varint() // timestamp delta
+				batch.varint() // offset delta
+				key := batch.next(int(batch.varint()))
+				value := batch.next(int(batch.varint()))
+				batch.varint() // headers
+				records <- producedRecord{topic: topic, partition: partition, key: string(key), value: value}
+			}
+			if d.err != nil || batch.err != nil {
+				t.Errorf("malformed request: %v %v", d.err, batch.err)
+				return
+			}
+
+			var resp kafkaEncoder
+			resp.int32(correlationID)
+			resp.int32(1)
+			resp.string(topic)
+			resp.int32(1)
+			resp.int32(partition)
+			resp.int16(errorCode)
+			resp.int64(0)
+			resp.int64(-1)
+			resp.int32(0) // throttle time
+			var frame kafkaEncoder
+			frame.bytes(resp.buf)
+			if _, err := conn.Write(frame.buf); err != nil {
+				return
+			}
+		}
+	}()
+	return ln.Addr().String(), records
+}
+
+func TestKafkaSink_Publish(t *testing.T) {
+	addr, records := standInBroker(t, 0)
+	s := NewKafkaSink(NewKafkaProducer(KafkaProducerConfig{
+		Broker:    addr,
+		Topic:     "tracking-events",
+		Partition: 2,
+		Acks:      1,
+		Timeout:   time.Second,
+	}))
+	defer s.Close()
+
+	events := []*model.TrackingEvent{
+		{EventType: model.TrackingEventTypeImpression, LineItemID: "li_1", Placement: "homepage_top"},
+		{EventType: model.TrackingEventTypeClick, LineItemID: "li_2", Placement: "homepage_top"},
+	}
+	for _, event := range events {
+		if err := s.Publish(event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	for _, want := range events {
+		got := <-records
+		if got.topic != "tracking-events" || got.partition != 2 {
+			t.Errorf("record sent to %s/%d, want tracking-events/2", got.topic, got.partition)
+		}
+		if got.key != want.LineItemID {
+			t.Errorf("record key = %q, want %q", got.key, want.LineItemID)
+		}
+		var event model.TrackingEvent
+		if err := json.Unmarshal(got.value, &event); err != nil {
+			t.Fatalf("record value is not a tracking event: %v", err)
+		}
+		if event.EventType != want.EventType {
+			t.Errorf("record event type = %q, want %q", event.EventType, want.EventType)
+		}
+	}
+}
+
+func TestKafkaSink_PublishBrokerError(t *testing.T) {
+	addr, _ := standInBroker(t, 6) // NOT_LEADER_OR_FOLLOWER
+	s := NewKafkaSink(NewKafkaProducer(KafkaProducerConfig{Broker: addr, Topic: "t", Acks: 1, Timeout: time.Second}))
+	defer s.Close()
+
+	err := s.Publish(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: "li_1"})
+	kerr, ok := err.(*KafkaError)
+	if !ok || kerr.Code != 6 {
+		t.Errorf("Publish() error = %v, want kafka error code 6", err)
+	}
+}
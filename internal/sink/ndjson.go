@@ -0,0 +1,63 @@
+package sink
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"sweng-task/internal/model"
+)
+
+var _ EventSink = (*NDJSONSink)(nil)
+
+// NDJSONSink writes one JSON encoded event per line to the underlying writer
+type NDJSONSink struct {
+	name string
+	w    io.Writer
+	c    io.Closer
+	mu   sync.Mutex
+}
+
+// NewNDJSONSink creates a sink writing to w. Closing the sink does not close w.
+func NewNDJSONSink(name string, w io.Writer) *NDJSONSink {
+	return &NDJSONSink{name: name, w: w}
+}
+
+// NewStdoutSink creates a NDJSON sink writing to standard output
+func NewStdoutSink() *NDJSONSink {
+	return NewNDJSONSink(TypeStdout, os.Stdout)
+}
+
+// NewFileSink creates a NDJSON sink appending to the file at path
+func NewFileSink(path string) (*NDJSONSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &NDJSONSink{name: TypeFile, w: f, c: f}, nil
+}
+
+func (s *NDJSONSink) Name() string {
+	return s.name
+}
+
+func (s *NDJSONSink) Publish(event *model.TrackingEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(line)
+	return err
+}
+
+func (s *NDJSONSink) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
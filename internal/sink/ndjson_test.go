@@ -0,0 +1,34 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"sweng-task/internal/model"
+)
+
+func TestNDJSONSink_Publish(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewNDJSONSink("test", &buf)
+	for _, lineItemID := range []string{"li_1", "li_2"} {
+		if err := s.Publish(&model.TrackingEvent{EventType: model.TrackingEventTypeImpression, LineItemID: lineItemID}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	for i, want := range []string{"li_1", "li_2"} {
+		var event model.TrackingEvent
+		if err := json.Unmarshal([]byte(lines[i]), &event); err != nil {
+			t.Fatalf("line %d is not a tracking event: %v", i, err)
+		}
+		if event.LineItemID != want {
+			t.Errorf("line %d line item = %q, want %q", i, event.LineItemID, want)
+		}
+	}
+}
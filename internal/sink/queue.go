@@ -0,0 +1,73 @@
+package sink
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrQueueFull is returned when an item is dropped because the delivery queue is full
+var ErrQueueFull = errors.New("sink queue is full")
+
+// queue is a bounded in-process queue drained by a single background worker,
+// so slow destinations do not block publishers. Items are dropped and counted
+// when the queue is full.
+type queue[T any] struct {
+	items   chan T
+	deliver func(T)
+	dropped atomic.Int64
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newQueue[T any](size int, deliver func(T)) *queue[T] {
+	q := &queue[T]{
+		items:   make(chan T, max(size, 1)),
+		deliver: deliver,
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *queue[T]) run() {
+	defer close(q.done)
+	for item := range q.items {
+		q.deliver(item)
+	}
+}
+
+// push enqueues item without blocking, and returns ErrQueueFull when it is dropped
+func (q *queue[T]) push(item T) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		q.dropped.Add(1)
+		return ErrQueueFull
+	}
+	select {
+	case q.items <- item:
+		return nil
+	default:
+		q.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// close stops accepting items and waits until the queued items are delivered
+func (q *queue[T]) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.items)
+	}
+	q.mu.Unlock()
+	<-q.done
+}
+
+// len returns the number of queued items
+func (q *queue[T]) len() int {
+	return len(q.items)
+}
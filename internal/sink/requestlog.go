@@ -0,0 +1,127 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+)
+
+// RequestLog writes ad requests as one JSON object per line. Requests are
+// queued and written by a background worker, so writes and rotations do not
+// block ad serving. Requests are dropped when the queue is full.
+type RequestLog struct {
+	w   io.Writer
+	c   io.Closer
+	q   *queue[*model.AdRequest]
+	log *zap.SugaredLogger
+}
+
+// NewRequestLog creates a request log writing to w, queueing up to queueSize
+// requests. Closing the log does not close w.
+func NewRequestLog(w io.Writer, queueSize int, log *zap.SugaredLogger) *RequestLog {
+	l := &RequestLog{w: w, log: log}
+	l.q = newQueue(queueSize, l.write)
+	return l
+}
+
+// OpenRequestLog creates a request log appending to a RotatingFile at path
+func OpenRequestLog(path string, maxBytes int64, maxAge time.Duration, queueSize int, log *zap.SugaredLogger) (*RequestLog, error) {
+	rf, err := NewRotatingFile(path, maxBytes, maxAge)
+	if err != nil {
+		return nil, err
+	}
+	l := NewRequestLog(rf, queueSize, log)
+	l.c = rf
+	return l, nil
+}
+
+// Publish queues the request without waiting for the write, and returns
+// ErrQueueFull when it is dropped. Write failures are only logged.
+func (l *RequestLog) Publish(request *model.AdRequest) error {
+	return l.q.push(request)
+}
+
+func (l *RequestLog) write(request *model.AdRequest) {
+	line, err := json.Marshal(request)
+	if err == nil {
+		_, err = l.w.Write(append(line, '\n'))
+	}
+	if err != nil {
+		l.log.Warnw("ad request log write failed",
+			"id", request.ID,
+			"error", err,
+		)
+	}
+}
+
+// Dropped returns the number of requests dropped because the queue was full
+func (l *RequestLog) Dropped() int64 {
+	return l.q.dropped.Load()
+}
+
+// Close flushes the queued requests and closes the file of the log
+func (l *RequestLog) Close() error {
+	l.q.close()
+	if l.c == nil {
+		return nil
+	}
+	return l.c.Close()
+}
+
+// ReadRequestLog calls fn with every ad request of the NDJSON request log r,
+// stopping at the first error
+func ReadRequestLog(r io.Reader, fn func(request *model.AdRequest) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var request model.AdRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(&request); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ReadRequestLogFiles reads the rotated files of the request log at path,
+// oldest first, and then the current file. Missing files are skipped.
+func ReadRequestLogFiles(path string, fn func(request *model.AdRequest) error) error {
+	rotated, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return err
+	}
+	// rotated file names end with their rotation time, so they sort in rotation order
+	slices.Sort(rotated)
+	for _, name := range append(rotated, path) {
+		if err := readRequestLogFile(name, fn); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func readRequestLogFile(name string, fn func(request *model.AdRequest) error) error {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ReadRequestLog(f, fn)
+}
@@ -0,0 +1,48 @@
+package sink
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"sweng-task/internal/model"
+)
+
+func TestRequestLog_ReadRequestLogFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.ndjson")
+	log, err := OpenRequestLog(path, 200, 0, 10, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("OpenRequestLog() error = %v", err)
+	}
+	ids := []string{"req_1", "req_2", "req_3", "req_4"}
+	for _, id := range ids {
+		request := &model.AdRequest{ID: id, Placement: "top", Winners: []model.AdRequestWinner{{LineItemID: "li_1", Bid: 0.5}}}
+		if err := log.Publish(request); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if rotated, _ := filepath.Glob(path + ".*"); len(rotated) == 0 {
+		t.Fatal("request log was not rotated")
+	}
+
+	var got []*model.AdRequest
+	err = ReadRequestLogFiles(path, func(request *model.AdRequest) error {
+		got = append(got, request)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadRequestLogFiles() error = %v", err)
+	}
+	if len(got) != len(ids) {
+		t.Fatalf("ReadRequestLogFiles() = %d requests, want %d", len(got), len(ids))
+	}
+	for i, request := range got {
+		if request.ID != ids[i] || len(request.Winners) != 1 || request.Winners[0].Bid != 0.5 {
+			t.Errorf("request %d = %+v, want %s with its winner", i, request, ids[i])
+		}
+	}
+}
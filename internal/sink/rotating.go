@@ -0,0 +1,106 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an append-only file which is rotated when it exceeds
+// MaxBytes or becomes older than MaxAge. Rotated files are renamed to
+// "<path>.<timestamp>" and a fresh file is opened at path.
+type RotatingFile struct {
+	path     string
+	maxBytes int64
+	maxAge   time.Duration
+
+	f        *os.File
+	size     int64
+	openedAt time.Time
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// NewRotatingFile opens (or creates) the file at path. A zero maxBytes or
+// maxAge disables the corresponding rotation trigger.
+func NewRotatingFile(path string, maxBytes int64, maxAge time.Duration) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:     path,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		now:      time.Now,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f = f
+	r.size = info.Size()
+	r.openedAt = r.now()
+	return nil
+}
+
+func (r *RotatingFile) shouldRotate(next int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.maxBytes > 0 && r.size+int64(next) > r.maxBytes {
+		return true
+	}
+	return r.maxAge > 0 && r.now().Sub(r.openedAt) >= r.maxAge
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	rotated := fmt.Sprintf("%s.%s", r.path, r.now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(r.path, rotated); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Write appends p to the current file, rotating beforehand when needed.
+// A single write is never split across files.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shouldRotate(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// NewRotatingFileSink creates a NDJSON sink on top of a RotatingFile
+func NewRotatingFileSink(path string, maxBytes int64, maxAge time.Duration) (*NDJSONSink, error) {
+	rf, err := NewRotatingFile(path, maxBytes, maxAge)
+	if err != nil {
+		return nil, err
+	}
+	return &NDJSONSink{name: TypeRotating, w: rf, c: rf}, nil
+}
@@ -0,0 +1,76 @@
+package sink
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	r, err := NewRotatingFile(path, 10, 0)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cc\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) != 1 {
+		t.Fatalf("rotated files = %v, want 1", rotated)
+	}
+	if got, _ := os.ReadFile(rotated[0]); string(got) != "aaaaaa\n" {
+		t.Errorf("rotated file = %q, want %q", got, "aaaaaa\n")
+	}
+	// a write is never split across files
+	if got, _ := os.ReadFile(path); string(got) != "bbbbbb\ncc\n" {
+		t.Errorf("current file = %q, want %q", got, "bbbbbb\ncc\n")
+	}
+}
+
+func TestRotatingFile_RotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	r, err := NewRotatingFile(path, 0, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	r.openedAt = now
+
+	write := func(line string) {
+		t.Helper()
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	write("first\n")
+	now = now.Add(59 * time.Minute)
+	write("second\n")
+	if rotated, _ := filepath.Glob(path + ".*"); len(rotated) != 0 {
+		t.Fatalf("rotated files = %v before max age", rotated)
+	}
+	now = now.Add(time.Minute)
+	write("third\n")
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) != 1 {
+		t.Fatalf("rotated files = %v, want 1", rotated)
+	}
+	if got, _ := os.ReadFile(rotated[0]); string(got) != "first\nsecond\n" {
+		t.Errorf("rotated file = %q, want %q", got, "first\nsecond\n")
+	}
+	if got, _ := os.ReadFile(path); string(got) != "third\n" {
+		t.Errorf("current file = %q, want %q", got, "third\n")
+	}
+}
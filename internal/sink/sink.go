@@ -0,0 +1,21 @@
+package sink
+
+import (
+	"sweng-task/internal/model"
+)
+
+// EventSink delivers tracking events to an external destination
+type EventSink interface {
+	// Name identifies the sink in logs and metrics
+	Name() string
+	Publish(event *model.TrackingEvent) error
+	Close() error
+}
+
+// Supported sink types for configuration
+const (
+	TypeStdout   = "stdout"
+	TypeFile     = "file"
+	TypeRotating = "rotating"
+	TypeKafka    = "kafka"
+)
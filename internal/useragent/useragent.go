@@ -0,0 +1,99 @@
+// Package useragent derives the device of an ad request from its User-Agent header.
+// It recognizes the common browsers and platforms by their well known tokens,
+// which is sufficient for targeting, not for analytics.
+package useragent
+
+import (
+	"strings"
+
+	"sweng-task/internal/model"
+)
+
+// Operating systems and browsers reported by Parse
+const (
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSIOS      = "ios"
+	OSAndroid  = "android"
+	OSChromeOS = "chromeos"
+	OSLinux    = "linux"
+
+	BrowserEdge    = "edge"
+	BrowserOpera   = "opera"
+	BrowserSamsung = "samsung"
+	BrowserChrome  = "chrome"
+	BrowserFirefox = "firefox"
+	BrowserSafari  = "safari"
+)
+
+// token maps a User-Agent token to a value, the first matching token wins
+type token struct {
+	substr string
+	value  string
+}
+
+var osTokens = []token{
+	{"windows", OSWindows},
+	{"iphone", OSIOS},
+	{"ipad", OSIOS},
+	{"ipod", OSIOS},
+	{"android", OSAndroid},
+	{"cros", OSChromeOS},
+	{"mac os x", OSMacOS},
+	{"macintosh", OSMacOS},
+	{"linux", OSLinux},
+}
+
+var browserTokens = []token{
+	{"edg/", BrowserEdge},
+	{"edga/", BrowserEdge},
+	{"edgios/", BrowserEdge},
+	{"opr/", BrowserOpera},
+	{"samsungbrowser/", BrowserSamsung},
+	{"firefox/", BrowserFirefox},
+	{"fxios/", BrowserFirefox},
+	{"crios/", BrowserChrome},
+	{"chrome/", BrowserChrome},
+	{"safari/", BrowserSafari},
+}
+
+var ctvTokens = []string{"smart-tv", "smarttv", "appletv", "roku", "crkey", "hbbtv", "webos", "bravia"}
+
+// Parse returns the device described by the User-Agent, leaving unknown fields empty
+func Parse(userAgent string) model.Device {
+	if userAgent == "" {
+		return model.Device{}
+	}
+	ua := strings.ToLower(userAgent)
+	return model.Device{
+		Type:    deviceType(ua),
+		OS:      match(ua, osTokens),
+		Browser: match(ua, browserTokens),
+	}
+}
+
+func deviceType(ua string) model.DeviceType {
+	for _, t := range ctvTokens {
+		if strings.Contains(ua, t) {
+			return model.DeviceTypeCTV
+		}
+	}
+	switch {
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet"):
+		return model.DeviceTypeTablet
+	case strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return model.DeviceTypeTablet
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "ipod"):
+		return model.DeviceTypeMobile
+	}
+	return model.DeviceTypeDesktop
+}
+
+func match(ua string, tokens []token) string {
+	for _, t := range tokens {
+		if strings.Contains(ua, t.substr) {
+			return t.value
+		}
+	}
+	return ""
+}
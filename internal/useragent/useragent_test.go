@@ -0,0 +1,59 @@
+package useragent
+
+import (
+	"testing"
+
+	"sweng-task/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      model.Device
+	}{
+		{
+			"chrome on windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			model.Device{Type: model.DeviceTypeDesktop, OS: OSWindows, Browser: BrowserChrome},
+		},
+		{
+			"edge on windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+			model.Device{Type: model.DeviceTypeDesktop, OS: OSWindows, Browser: BrowserEdge},
+		},
+		{
+			"safari on iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			model.Device{Type: model.DeviceTypeMobile, OS: OSIOS, Browser: BrowserSafari},
+		},
+		{
+			"chrome on android phone",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+			model.Device{Type: model.DeviceTypeMobile, OS: OSAndroid, Browser: BrowserChrome},
+		},
+		{
+			"android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			model.Device{Type: model.DeviceTypeTablet, OS: OSAndroid, Browser: BrowserChrome},
+		},
+		{
+			"firefox on mac",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+			model.Device{Type: model.DeviceTypeDesktop, OS: OSMacOS, Browser: BrowserFirefox},
+		},
+		{
+			"smart tv",
+			"Mozilla/5.0 (SMART-TV; Linux; Tizen 6.0) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/4.0 Chrome/76.0.3809.146 TV Safari/537.36",
+			model.Device{Type: model.DeviceTypeCTV, OS: OSLinux, Browser: BrowserSamsung},
+		},
+		{"empty", "", model.Device{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.userAgent); got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package vast builds VAST 4.2 documents of video ads
+package vast
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"sweng-task/internal/model"
+)
+
+// Version is the VAST version of the documents
+const Version = "4.2"
+
+// adSystem is the name of the ad server in the documents
+const adSystem = "sweng-task"
+
+// VAST is the root element of a VAST document. A document without ads is the
+// VAST no-ad response.
+type VAST struct {
+	XMLName xml.Name `xml:"VAST"`
+	Version string   `xml:"version,attr"`
+	Ads     []Ad     `xml:"Ad"`
+}
+
+// Ad is an inline ad, ads with a sequence form an ad pod
+type Ad struct {
+	ID       string `xml:"id,attr"`
+	Sequence int    `xml:"sequence,attr,omitempty"`
+	InLine   InLine `xml:"InLine"`
+}
+
+// InLine contains the ad and its creatives
+type InLine struct {
+	AdSystem    string     `xml:"AdSystem"`
+	AdTitle     string     `xml:"AdTitle"`
+	AdServingID string     `xml:"AdServingId"`
+	Impressions []URI      `xml:"Impression"`
+	Creatives   []Creative `xml:"Creatives>Creative"`
+}
+
+// URI is an element whose content is a URL
+type URI struct {
+	ID  string `xml:"id,attr,omitempty"`
+	URL string `xml:",cdata"`
+}
+
+// Creative is a linear video creative
+type Creative struct {
+	ID            string        `xml:"id,attr"`
+	AdID          string        `xml:"adId,attr,omitempty"`
+	UniversalAdID UniversalAdID `xml:"UniversalAdId"`
+	Linear        Linear        `xml:"Linear"`
+}
+
+// UniversalAdID identifies the creative across systems
+type UniversalAdID struct {
+	IDRegistry string `xml:"idRegistry,attr"`
+	Value      string `xml:",chardata"`
+}
+
+// Linear contains the media files and trackers of a linear creative
+type Linear struct {
+	Duration       string      `xml:"Duration"`
+	TrackingEvents []Tracking  `xml:"TrackingEvents>Tracking"`
+	VideoClicks    VideoClicks `xml:"VideoClicks"`
+	MediaFiles     []MediaFile `xml:"MediaFiles>MediaFile"`
+}
+
+// Tracking is the tracker of a playback event
+type Tracking struct {
+	Event string `xml:"event,attr"`
+	URL   string `xml:",cdata"`
+}
+
+// VideoClicks contains the landing page and click trackers
+type VideoClicks struct {
+	ClickThrough  URI   `xml:"ClickThrough"`
+	ClickTracking []URI `xml:"ClickTracking"`
+}
+
+// MediaFile is a video file of the creative
+type MediaFile struct {
+	Delivery string `xml:"delivery,attr"`
+	Type     string `xml:"type,attr"`
+	Width    int    `xml:"width,attr"`
+	Height   int    `xml:"height,attr"`
+	Bitrate  int    `xml:"bitrate,attr,omitempty"`
+	URL      string `xml:",cdata"`
+}
+
+// playbackEvents maps VAST playback events to tracking event types
+var playbackEvents = []struct {
+	vast      string
+	eventType model.TrackingEventType
+}{
+	{"start", model.TrackingEventTypeVideoStart},
+	{"firstQuartile", model.TrackingEventTypeVideoFirstQuartile},
+	{"midpoint", model.TrackingEventTypeVideoMidpoint},
+	{"thirdQuartile", model.TrackingEventTypeVideoThirdQuartile},
+	{"complete", model.TrackingEventTypeVideoComplete},
+}
+
+// TrackerFunc returns the tracking URL of an event of an ad
+type TrackerFunc func(ad *model.Ad, eventType model.TrackingEventType) string
+
+// Build returns the VAST document of the video ads, with impression, playback
+// and click trackers from tracker. Ads without a video creative are skipped,
+// and several ads are returned as a pod in their order.
+func Build(ads []*model.Ad, tracker TrackerFunc) *VAST {
+	doc := &VAST{Version: Version}
+	for _, ad := range ads {
+		creative := ad.Creative
+		if creative == nil || creative.Format != model.CreativeFormatVideo || creative.Video == nil {
+			continue
+		}
+		linear := Linear{
+			Duration: duration(creative.Video.Duration),
+			VideoClicks: VideoClicks{
+				ClickThrough:  URI{URL: creative.LandingURL},
+				ClickTracking: []URI{{URL: tracker(ad, model.TrackingEventTypeClick)}},
+			},
+			MediaFiles: []MediaFile{{
+				Delivery: "progressive",
+				Type:     creative.Video.MimeType,
+				Width:    creative.Width,
+				Height:   creative.Height,
+				Bitrate:  creative.Video.Bitrate,
+				URL:      creative.Video.MediaURL,
+			}},
+		}
+		for _, event := range playbackEvents {
+			linear.TrackingEvents = append(linear.TrackingEvents, Tracking{Event: event.vast, URL: tracker(ad, event.eventType)})
+		}
+		doc.Ads = append(doc.Ads, Ad{
+			ID: ad.ID,
+			InLine: InLine{
+				AdSystem:    adSystem,
+				AdTitle:     ad.Name,
+				AdServingID: ad.ID + ":" + creative.ID,
+				Impressions: []URI{{URL: tracker(ad, model.TrackingEventTypeImpression)}},
+				Creatives: []Creative{{
+					ID:            creative.ID,
+					AdID:          ad.ID,
+					UniversalAdID: UniversalAdID{IDRegistry: "unknown", Value: creative.ID},
+					Linear:        linear,
+				}},
+			},
+		})
+	}
+	if len(doc.Ads) > 1 {
+		for i := range doc.Ads {
+			doc.Ads[i].Sequence = i + 1
+		}
+	}
+	return doc
+}
+
+// Marshal returns the XML document with its declaration
+func (v *VAST) Marshal() ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// duration formats seconds as the HH:MM:SS duration of VAST
+func duration(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
@@ -0,0 +1,72 @@
+package vast
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"sweng-task/internal/model"
+)
+
+func TestBuild(t *testing.T) {
+	video := &model.Ad{ID: "li_1", Name: "Launch", Creative: &model.Creative{
+		ID: "cr_1", Format: model.CreativeFormatVideo, Width: 1280, Height: 720, LandingURL: "https://brand.example",
+		Video: &model.VideoAsset{MediaURL: "https://cdn.example/a.mp4", MimeType: "video/mp4", Duration: 75},
+	}}
+	image := &model.Ad{ID: "li_2", Creative: &model.Creative{ID: "cr_2", Format: model.CreativeFormatImage}}
+	tracker := func(ad *model.Ad, eventType model.TrackingEventType) string {
+		return "https://ads.example/t?line_item_id=" + ad.ID + "&event_type=" + string(eventType)
+	}
+
+	doc := Build([]*model.Ad{video, image}, tracker)
+	if len(doc.Ads) != 1 || doc.Ads[0].Sequence != 0 {
+		t.Fatalf("Build() ads = %+v, want the video ad without sequence", doc.Ads)
+	}
+	inline := doc.Ads[0].InLine
+	if len(inline.Impressions) != 1 || !strings.HasSuffix(inline.Impressions[0].URL, "event_type=impression") {
+		t.Errorf("Impression = %+v, want impression tracker", inline.Impressions)
+	}
+	linear := inline.Creatives[0].Linear
+	if linear.Duration != "00:01:15" {
+		t.Errorf("Duration = %q, want 00:01:15", linear.Duration)
+	}
+	wantEvents := map[string]string{
+		"start":         "video_start",
+		"firstQuartile": "video_first_quartile",
+		"midpoint":      "video_midpoint",
+		"thirdQuartile": "video_third_quartile",
+		"complete":      "video_complete",
+	}
+	for _, tracking := range linear.TrackingEvents {
+		if !strings.HasSuffix(tracking.URL, "event_type="+wantEvents[tracking.Event]) {
+			t.Errorf("Tracking %s = %s, want %s tracker", tracking.Event, tracking.URL, wantEvents[tracking.Event])
+		}
+	}
+	if len(linear.TrackingEvents) != len(wantEvents) {
+		t.Errorf("TrackingEvents = %d, want %d", len(linear.TrackingEvents), len(wantEvents))
+	}
+	if linear.VideoClicks.ClickThrough.URL != "https://brand.example" ||
+		!strings.HasSuffix(linear.VideoClicks.ClickTracking[0].URL, "event_type=click") {
+		t.Errorf("VideoClicks = %+v, want landing page and click tracker", linear.VideoClicks)
+	}
+
+	body, err := doc.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var parsed VAST
+	if err := xml.Unmarshal(body, &parsed); err != nil || parsed.Version != Version || len(parsed.Ads) != 1 {
+		t.Errorf("Marshal() = %s, want a parseable VAST %s document", body, Version)
+	}
+	if !strings.Contains(string(body), "<![CDATA[https://ads.example/t?line_item_id=li_1&event_type=impression]]>") {
+		t.Errorf("Marshal() = %s, want URLs in CDATA", body)
+	}
+
+	pod := Build([]*model.Ad{video, video}, tracker)
+	if len(pod.Ads) != 2 || pod.Ads[0].Sequence != 1 || pod.Ads[1].Sequence != 2 {
+		t.Errorf("Build() pod = %+v, want sequenced ads", pod.Ads)
+	}
+	if empty := Build(nil, tracker); len(empty.Ads) != 0 {
+		t.Errorf("Build() without ads = %+v, want no-ad response", empty)
+	}
+}
@@ -0,0 +1,67 @@
+// Package webhook posts alerts as JSON to an HTTP endpoint
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sweng-task/internal/model"
+)
+
+// EventUnderdelivery is the event of a line item forecast to underdeliver
+const EventUnderdelivery = "line_item.underdelivery"
+
+// Alert is the body of a webhook request
+type Alert struct {
+	Event     string                  `json:"event"`
+	Timestamp time.Time               `json:"timestamp"`
+	Delivery  *model.LineItemDelivery `json:"delivery"`
+}
+
+// Client posts alerts to a webhook URL
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// New creates a new Client posting to url, requests time out after timeout
+func New(url string, timeout time.Duration) *Client {
+	return &Client{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// NotifyUnderdelivery posts an underdelivery alert of the line item delivery
+func (c *Client) NotifyUnderdelivery(ctx context.Context, delivery *model.LineItemDelivery) error {
+	return c.post(ctx, Alert{
+		Event:     EventUnderdelivery,
+		Timestamp: time.Now().UTC(),
+		Delivery:  delivery,
+	})
+}
+
+func (c *Client) post(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sweng-task/internal/model"
+)
+
+func TestClient_NotifyUnderdelivery(t *testing.T) {
+	var got Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s %s, want JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if got.Delivery.LineItemID == "li_fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	c := New(server.URL, time.Second)
+
+	err := c.NotifyUnderdelivery(context.Background(), &model.LineItemDelivery{LineItemID: "li_1", ImpressionGoal: 100, Forecast: 40})
+	if err != nil {
+		t.Fatalf("NotifyUnderdelivery() error = %v", err)
+	}
+	if got.Event != EventUnderdelivery || got.Delivery.LineItemID != "li_1" || got.Delivery.Forecast != 40 {
+		t.Errorf("alert = %+v, want underdelivery of li_1", got)
+	}
+
+	if err := c.NotifyUnderdelivery(context.Background(), &model.LineItemDelivery{LineItemID: "li_fail"}); err == nil {
+		t.Error("NotifyUnderdelivery() error = nil, want error on failed webhook")
+	}
+}